@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
+	"crypto/subtle"
 	"embed"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"io/fs"
 	"log"
 	"log/slog"
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/PumpkinSeed/letherscan/pkg/communicator"
 	"github.com/go-chi/chi/v5"
@@ -19,7 +25,25 @@ import (
 var embeddedFiles embed.FS
 
 const (
-	EnvHost = "HOST"
+	EnvHost        = "HOST"
+	EnvNodeAddress = "NODE_ADDRESS"
+	EnvIndexerPath = "INDEXER_PATH"
+	EnvArtifacts   = "ARTIFACTS_PATH"
+	EnvContracts   = "CONTRACTS_PATH"
+	EnvSignatures  = "SIGNATURES_PATH"
+
+	EnvAllowedOrigins = "ALLOWED_ORIGINS"
+	EnvAPIToken       = "API_TOKEN"
+
+	EnvMnemonic         = "MNEMONIC"
+	EnvDerivationPath   = "DERIVATION_PATH"
+	EnvMnemonicAccounts = "MNEMONIC_ACCOUNTS"
+	EnvKeystorePath     = "KEYSTORE_PATH"
+	EnvKeystorePassword = "KEYSTORE_PASSWORD"
+
+	DefaultContractsPath  = "contracts.json"
+	DefaultSignaturesPath = "signatures.txt"
+	DefaultAllowedOrigins = "http://localhost:*,http://127.0.0.1:*"
 
 	NodeAddressHeaderKey = "X-Node-Address"
 )
@@ -28,25 +52,113 @@ func main() {
 	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelInfo,
 	})))
+	nodeAddress := communicator.DefaultNodeAddress
+	if envNodeAddress := os.Getenv(EnvNodeAddress); envNodeAddress != "" {
+		nodeAddress = envNodeAddress
+	}
+
+	// Index the configured node in the background if a store path is provided
+	if indexerPath := os.Getenv(EnvIndexerPath); indexerPath != "" {
+		store, err := communicator.OpenStore(indexerPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer store.Close()
+
+		indexer := communicator.NewIndexer(nodeAddress, store, communicator.DefaultIndexerPollInterval)
+		communicator.SetIndexer(indexer)
+		go func() {
+			if err := indexer.Run(context.Background()); err != nil {
+				slog.Error("indexer stopped", "error", err)
+			}
+		}()
+	}
+
+	// Signatures imported through the API extend the bundled ones, registered ABIs are added on load
+	signaturesPath := DefaultSignaturesPath
+	if envSignaturesPath := os.Getenv(EnvSignatures); envSignaturesPath != "" {
+		signaturesPath = envSignaturesPath
+	}
+	signatureDatabase, err := communicator.OpenSignatureDatabase(signaturesPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	communicator.SetSignatureDatabase(signatureDatabase)
+
+	// Contracts saved through the API are kept in a local file
+	contractsPath := DefaultContractsPath
+	if envContractsPath := os.Getenv(EnvContracts); envContractsPath != "" {
+		contractsPath = envContractsPath
+	}
+	contractRegistry, err := communicator.OpenContractRegistry(contractsPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	communicator.SetContractRegistry(contractRegistry)
+
+	// Accounts requests can sign with, the Hardhat and Anvil default accounts unless configured otherwise
+	signerConfig := communicator.SignerConfig{
+		Mnemonic:         communicator.DefaultMnemonic,
+		DerivationPath:   os.Getenv(EnvDerivationPath),
+		KeystorePath:     os.Getenv(EnvKeystorePath),
+		KeystorePassword: os.Getenv(EnvKeystorePassword),
+	}
+	if envMnemonic := os.Getenv(EnvMnemonic); envMnemonic != "" {
+		signerConfig.Mnemonic = envMnemonic
+	}
+	if envMnemonicAccounts := os.Getenv(EnvMnemonicAccounts); envMnemonicAccounts != "" {
+		signerConfig.Accounts, err = strconv.Atoi(envMnemonicAccounts)
+		if err != nil {
+			log.Fatalf("invalid %s: %v", EnvMnemonicAccounts, err)
+		}
+	}
+	// Keystore keys are real keys, they are only unlocked if signing requires the API token
+	apiToken := os.Getenv(EnvAPIToken)
+	if signerConfig.KeystorePath != "" && apiToken == "" {
+		slog.Warn("keystore signers are disabled without an API token", slog.String("keystore_path", signerConfig.KeystorePath), slog.String("required", EnvAPIToken))
+		signerConfig.KeystorePath = ""
+	}
+	signers, err := communicator.LoadSigners(signerConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	communicator.SetSignerRegistry(signers)
+
+	// Load the compiled contracts of a Hardhat, Foundry or Truffle project and reload them on recompile
+	if artifactsPath := os.Getenv(EnvArtifacts); artifactsPath != "" {
+		registry := communicator.NewArtifactRegistry(artifactsPath, communicator.DefaultArtifactPollInterval)
+		communicator.SetArtifactRegistry(registry)
+		go func() {
+			if err := registry.Run(context.Background()); err != nil {
+				slog.Error("artifact registry stopped", "error", err)
+			}
+		}()
+	}
+
 	r := chi.NewRouter()
 
-	// CORS middleware setup
+	// CORS middleware setup, only local pages can call the API unless other origins are configured
+	allowedOrigins := DefaultAllowedOrigins
+	if envAllowedOrigins := os.Getenv(EnvAllowedOrigins); envAllowedOrigins != "" {
+		allowedOrigins = envAllowedOrigins
+	}
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"https://*", "http://*"},
+		AllowedOrigins:   strings.Split(allowedOrigins, ","),
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", NodeAddressHeaderKey},
 		ExposedHeaders:   []string{"Link"},
-		AllowCredentials: true,
+		AllowCredentials: false,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}))
 
 	// Get Node Address from header and set it in context
 	r.Use(func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
-			nodeAddress := r.Header.Get(NodeAddressHeaderKey)
-			if nodeAddress != "" {
-				r = r.WithContext(communicator.SetNodeAddress(r.Context(), nodeAddress))
+			requestNodeAddress := r.Header.Get(NodeAddressHeaderKey)
+			if requestNodeAddress == "" {
+				requestNodeAddress = nodeAddress
 			}
+			r = r.WithContext(communicator.SetNodeAddress(r.Context(), requestNodeAddress))
 
 			next.ServeHTTP(w, r)
 		}
@@ -78,10 +190,55 @@ func main() {
 
 	r.Get("/blocks", getBlocks)
 	r.Get("/transaction/{hash}", getTransactionByHash)
+	r.Get("/transaction/{hash}/trace", traceTransaction)
+	r.Post("/transaction/{hash}/trace", traceTransaction)
+	r.Get("/transaction/{hash}/state-diff", getStateDiff)
+	r.Post("/transaction/{hash}/state-diff", getStateDiff)
+	r.Get("/transaction/{hash}/gas-profile", getGasProfile)
+	r.Post("/transaction/{hash}/gas-profile", getGasProfile)
+	r.Get("/transaction/{hash}/debug", getDebugSteps)
+	r.Post("/transaction/{hash}/debug", getDebugSteps)
+	r.Get("/transaction/{hash}/debug/find", findDebugStep)
+	r.Post("/transaction/{hash}/debug/find", findDebugStep)
+	r.Get("/address/{address}", getAddress)
+	r.Get("/chain-resets", getChainResets)
+	r.Get("/artifacts", getArtifacts)
+	r.Get("/contracts", getContracts)
+	r.Post("/contracts", saveContract)
+	r.Get("/contracts/{address}", getContract)
+	r.Delete("/contracts/{address}", deleteContract)
+	r.Get("/signatures/{hash}", lookupSignature)
+	r.Get("/signers", getSigners)
+	r.Get("/dev-node", getDevNode)
+	r.Post("/dev-node/mine", mine)
+	r.Get("/dev-node/snapshots", getSnapshots)
+	r.Post("/dev-node/snapshots", takeSnapshot)
+	r.Post("/dev-node/snapshots/{snapshot}/revert", revertSnapshot)
+	r.Post("/dev-node/increase-time", increaseTime)
+	r.Post("/dev-node/next-block-timestamp", setNextBlockTimestamp)
+	r.Post("/dev-node/automine", setAutomine)
+	r.Post("/dev-node/interval-mining", setIntervalMining)
+	r.Post("/dev-node/set-balance", setBalance)
+	r.Post("/dev-node/set-code", setCode)
+	r.Post("/dev-node/set-storage-at", setStorageAt)
+	r.Post("/dev-node/set-nonce", setNonce)
+	r.Post("/dev-node/impersonate", impersonateAccount)
+	r.Post("/dev-node/stop-impersonating", stopImpersonatingAccount)
+	r.Post("/signatures", importSignatures)
+	r.Get("/stream", stream)
 	r.Post("/decode-contract-call-data", decodeContractCallData)
+	r.Post("/decode-logs", decodeLogs)
+	r.Post("/decode-revert", decodeRevert)
 	r.Post("/parse-contract-abi", parseContractABI)
 	r.Post("/eth-call", ethCall)
-	r.Post("/send-transaction", sendTransaction)
+	r.Post("/batch-call", batchCall)
+	r.Group(func(r chi.Router) {
+		if apiToken != "" {
+			r.Use(requireAPIToken(apiToken))
+		}
+		r.Post("/send-transaction", sendTransaction)
+		r.Post("/deploy", deployContract)
+	})
 
 	host := ":8080"
 	if envHost := os.Getenv(EnvHost); envHost != "" {
@@ -126,6 +283,39 @@ func sendTransaction(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func deployContract(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req communicator.DeployContractRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respStruct, err := communicator.DeployContract(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
 func ethCall(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -159,17 +349,17 @@ func ethCall(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func parseContractABI(w http.ResponseWriter, r *http.Request) {
+func batchCall(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	var req communicator.ParseContractABIRequest
+	var req communicator.BatchCallRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	respStruct, err := communicator.ParseContractABI(ctx, req)
+	respStruct, err := communicator.BatchCall(ctx, req)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -192,17 +382,17 @@ func parseContractABI(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func decodeContractCallData(w http.ResponseWriter, r *http.Request) {
+func decodeLogs(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	var req communicator.DecodeContractCallDataRequest
+	var req communicator.DecodeLogsRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	respStruct, err := communicator.DecodeContractCallData(ctx, req)
+	respStruct, err := communicator.DecodeLogs(ctx, req)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -215,20 +405,20 @@ func decodeContractCallData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
 	_, err = w.Write(data)
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set("Content-Type", "application/json")
 }
 
-func getTransactionByHash(w http.ResponseWriter, r *http.Request) {
+func lookupSignature(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	respStruct, err := communicator.GetTransactionByHash(ctx, communicator.GetTransactionByHashRequest{
+	respStruct, err := communicator.LookupSignature(ctx, communicator.LookupSignatureRequest{
 		Hash: chi.URLParam(r, "hash"),
 	})
 	if err != nil {
@@ -242,6 +432,7 @@ func getTransactionByHash(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+
 	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
 	_, err = w.Write(data)
@@ -252,24 +443,77 @@ func getTransactionByHash(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func getBlocks(w http.ResponseWriter, r *http.Request) {
+func importSignatures(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	numberOfBlocks := r.URL.Query().Get("number_of_blocks")
-	numberOfBlocksInt, err := strconv.Atoi(numberOfBlocks)
-	if err != nil || numberOfBlocksInt <= 0 {
-		numberOfBlocksInt = 3 // Default to 3 blocks if not provided or invalid
+	var req communicator.ImportSignaturesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	blockNumber := r.URL.Query().Get("block_number")
-	blockNumberInt, err := strconv.Atoi(blockNumber)
-	if err != nil || blockNumberInt < 0 {
-		blockNumberInt = 0
+	respStruct, err := communicator.ImportSignatures(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	respStruct, err := communicator.GetLatestNBlock(ctx, communicator.GetLatestNBlockRequest{
-		NumberOfBlocks: int64(numberOfBlocksInt),
-		BlockNumber:    int64(blockNumberInt),
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func saveContract(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req communicator.SaveContractRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respStruct, err := communicator.SaveContract(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func getContracts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	respStruct, err := communicator.GetContracts(ctx, communicator.GetContractsRequest{
+		Tag: r.URL.Query().Get("tag"),
 	})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -282,6 +526,7 @@ func getBlocks(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+
 	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
 	_, err = w.Write(data)
@@ -292,20 +537,1115 @@ func getBlocks(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func withCORS(h http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Set your CORS headers
-		w.Header().Set("Access-Control-Allow-Origin", "*") // or restrict to specific origin
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+func getContract(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 
-		// Handle preflight request
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent)
-			return
-		}
+	respStruct, err := communicator.GetContract(ctx, communicator.GetContractRequest{
+		Address: chi.URLParam(r, "address"),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-		// Call the original handler
-		h.ServeHTTP(w, r)
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func deleteContract(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := communicator.DeleteContract(ctx, communicator.DeleteContractRequest{
+		Address: chi.URLParam(r, "address"),
 	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func decodeRevert(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req communicator.DecodeRevertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respStruct, err := communicator.DecodeRevert(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func parseContractABI(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req communicator.ParseContractABIRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respStruct, err := communicator.ParseContractABI(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func decodeContractCallData(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req communicator.DecodeContractCallDataRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respStruct, err := communicator.DecodeContractCallData(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+}
+
+// traceTransaction accepts the contract ABIs in the body of POST requests
+func traceTransaction(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req communicator.TraceTransactionRequest
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	req.Hash = chi.URLParam(r, "hash")
+
+	respStruct, err := communicator.TraceTransaction(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// getStateDiff accepts the storage layouts in the body of POST requests
+func getStateDiff(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req communicator.GetStateDiffRequest
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	req.Hash = chi.URLParam(r, "hash")
+
+	respStruct, err := communicator.GetStateDiff(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// getDebugSteps pages through the cached struct log trace, artifacts are accepted in the body of POST requests
+func getDebugSteps(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req communicator.GetDebugStepsRequest
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	req.Hash = chi.URLParam(r, "hash")
+
+	offset, err := strconv.Atoi(r.URL.Query().Get("offset"))
+	if err == nil && offset > 0 {
+		req.Offset = offset
+	}
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err == nil && limit > 0 {
+		req.Limit = limit
+	}
+
+	respStruct, err := communicator.GetDebugSteps(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// findDebugStep searches the cached struct log trace from the given step, artifacts are accepted in the body
+// of POST requests
+func findDebugStep(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req communicator.FindDebugStepRequest
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	req.Hash = chi.URLParam(r, "hash")
+
+	query := r.URL.Query()
+	if from, err := strconv.Atoi(query.Get("from")); err == nil {
+		req.From = from
+	}
+	if kind := query.Get("kind"); kind != "" {
+		req.Kind = kind
+	}
+	if opcode := query.Get("opcode"); opcode != "" {
+		req.Opcode = opcode
+	}
+	if backwards, err := strconv.ParseBool(query.Get("backwards")); err == nil {
+		req.Backwards = backwards
+	}
+
+	respStruct, err := communicator.FindDebugStep(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// getGasProfile accepts the contract ABIs and artifacts in the body of POST requests, the
+// folded stacks are returned as plain text with format=folded
+func getGasProfile(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req communicator.GetGasProfileRequest
+	if r.Method == http.MethodPost {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	req.Hash = chi.URLParam(r, "hash")
+
+	respStruct, err := communicator.GetGasProfile(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "folded" {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write([]byte(respStruct.FoldedStacks)); err != nil {
+			slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		}
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func getTransactionByHash(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	respStruct, err := communicator.GetTransactionByHash(ctx, communicator.GetTransactionByHashRequest{
+		Hash: chi.URLParam(r, "hash"),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func getAddress(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	blockNumber := r.URL.Query().Get("block_number")
+	blockNumberInt, err := strconv.Atoi(blockNumber)
+	if err != nil || blockNumberInt < 0 {
+		blockNumberInt = 0
+	}
+
+	startBlock := r.URL.Query().Get("start_block")
+	startBlockInt, err := strconv.Atoi(startBlock)
+	if err != nil || startBlockInt < 0 {
+		startBlockInt = 0
+	}
+
+	pageSize := r.URL.Query().Get("page_size")
+	pageSizeInt, err := strconv.Atoi(pageSize)
+	if err != nil || pageSizeInt <= 0 {
+		pageSizeInt = 0 // Communicator falls back to its default
+	}
+
+	numberOfBlocks := r.URL.Query().Get("number_of_blocks")
+	numberOfBlocksInt, err := strconv.Atoi(numberOfBlocks)
+	if err != nil || numberOfBlocksInt <= 0 {
+		numberOfBlocksInt = 0 // Communicator falls back to its default
+	}
+
+	respStruct, err := communicator.GetAddress(ctx, communicator.GetAddressRequest{
+		Address:        chi.URLParam(r, "address"),
+		BlockNumber:    int64(blockNumberInt),
+		StartBlock:     int64(startBlockInt),
+		PageSize:       int64(pageSizeInt),
+		NumberOfBlocks: int64(numberOfBlocksInt),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func getChainResets(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	respStruct, err := communicator.GetChainResets(ctx, communicator.GetChainResetsRequest{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func getArtifacts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	respStruct, err := communicator.GetArtifacts(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func getSigners(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	respStruct, err := communicator.GetSigners(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func getDevNode(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	respStruct, err := communicator.GetDevNode(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func getSnapshots(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	respStruct, err := communicator.GetSnapshots(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func mine(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Every field is optional, an empty body is an empty request
+	var req communicator.MineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respStruct, err := communicator.Mine(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func takeSnapshot(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Every field is optional, an empty body is an empty request
+	var req communicator.TakeSnapshotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respStruct, err := communicator.TakeSnapshot(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func revertSnapshot(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	respStruct, err := communicator.RevertSnapshot(ctx, communicator.RevertSnapshotRequest{
+		Snapshot: chi.URLParam(r, "snapshot"),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func increaseTime(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req communicator.IncreaseTimeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respStruct, err := communicator.IncreaseTime(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func setNextBlockTimestamp(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req communicator.SetNextBlockTimestampRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respStruct, err := communicator.SetNextBlockTimestamp(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func setAutomine(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req communicator.SetAutomineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respStruct, err := communicator.SetAutomine(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func setIntervalMining(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req communicator.SetIntervalMiningRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respStruct, err := communicator.SetIntervalMining(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func setBalance(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req communicator.SetBalanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respStruct, err := communicator.SetBalance(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func setCode(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req communicator.SetCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respStruct, err := communicator.SetCode(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func setStorageAt(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req communicator.SetStorageAtRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respStruct, err := communicator.SetStorageAt(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func setNonce(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req communicator.SetNonceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respStruct, err := communicator.SetNonce(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func impersonateAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req communicator.ImpersonateAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respStruct, err := communicator.ImpersonateAccount(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func stopImpersonatingAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req communicator.ImpersonateAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.ErrorContext(ctx, "Failed to decode request body", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	respStruct, err := communicator.StopImpersonatingAccount(ctx, req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// stream pushes new blocks and pending transactions as Server-Sent Events
+func stream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	pendingTransactions, _ := strconv.ParseBool(r.URL.Query().Get("pending_transactions"))
+
+	events := make(chan communicator.StreamEvent)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- communicator.Stream(ctx, communicator.StreamRequest{
+			PendingTransactions: pendingTransactions,
+		}, events)
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-errCh:
+			if err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "Stream stopped", slog.Any("err", err))
+				fmt.Fprintf(w, "event: error\ndata: %q\n\n", err.Error())
+				flusher.Flush()
+			}
+			return
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to marshal event", slog.Any("err", err))
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				slog.ErrorContext(ctx, "Failed to write event", slog.Any("err", err))
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func getBlocks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	numberOfBlocks := r.URL.Query().Get("number_of_blocks")
+	numberOfBlocksInt, err := strconv.Atoi(numberOfBlocks)
+	if err != nil || numberOfBlocksInt <= 0 {
+		numberOfBlocksInt = 3 // Default to 3 blocks if not provided or invalid
+	}
+
+	blockNumber := r.URL.Query().Get("block_number")
+	blockNumberInt, err := strconv.Atoi(blockNumber)
+	if err != nil || blockNumberInt < 0 {
+		blockNumberInt = 0
+	}
+
+	respStruct, err := communicator.GetLatestNBlock(ctx, communicator.GetLatestNBlockRequest{
+		NumberOfBlocks: int64(numberOfBlocksInt),
+		BlockNumber:    int64(blockNumberInt),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(respStruct)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to marshal response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to write response", slog.Any("err", err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func withCORS(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Set your CORS headers
+		w.Header().Set("Access-Control-Allow-Origin", "*") // or restrict to specific origin
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+
+		// Handle preflight request
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		// Call the original handler
+		h.ServeHTTP(w, r)
+	})
+}
+
+// requireAPIToken rejects the requests without the token as bearer token, used for the routes that sign.
+func requireAPIToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				slog.InfoContext(r.Context(), "Rejected request without API token", slog.String("url", r.URL.String()))
+				http.Error(w, "missing or invalid API token", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
 }
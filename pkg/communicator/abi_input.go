@@ -0,0 +1,319 @@
+package communicator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Arguments holds the method arguments in their textual form. It can be decoded from a JSON array of
+// any values, strings are kept as they are and everything else (numbers, bools, arrays, objects) is
+// kept as its raw JSON text.
+type Arguments []string
+
+func (a *Arguments) UnmarshalJSON(data []byte) error {
+	var rawArgs []json.RawMessage
+	if err := json.Unmarshal(data, &rawArgs); err != nil {
+		return err
+	}
+
+	args := make(Arguments, 0, len(rawArgs))
+	for _, rawArg := range rawArgs {
+		var str string
+		if err := json.Unmarshal(rawArg, &str); err == nil {
+			args = append(args, str)
+			continue
+		}
+		args = append(args, string(rawArg))
+	}
+	*a = args
+
+	return nil
+}
+
+// convertArguments converts the textual arguments into the Go values expected by abi.Arguments.Pack.
+func convertArguments(inputs abi.Arguments, args []string) ([]interface{}, error) {
+	if len(args) != len(inputs) {
+		return nil, fmt.Errorf("expected %d arguments, got %d", len(inputs), len(args))
+	}
+
+	converted := make([]interface{}, 0, len(inputs))
+	for i, input := range inputs {
+		value, err := convertArgument(input.Type, args[i])
+		if err != nil {
+			name := input.Name
+			if name == "" {
+				name = fmt.Sprintf("input_%d", i)
+			}
+			return nil, fmt.Errorf("invalid %s argument %s: %v", input.Type.String(), name, err)
+		}
+		converted = append(converted, value)
+	}
+
+	return converted, nil
+}
+
+// convertArgument converts a JSON value (string, json.Number, bool, []interface{} or
+// map[string]interface{}) into the Go representation of the ABI type.
+func convertArgument(t abi.Type, value interface{}) (interface{}, error) {
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		return convertInteger(t, value)
+	case abi.BoolTy:
+		return convertBool(value)
+	case abi.AddressTy:
+		str, err := argumentString(value)
+		if err != nil {
+			return nil, err
+		}
+		if !common.IsHexAddress(str) {
+			return nil, fmt.Errorf("invalid address %q", str)
+		}
+		return common.HexToAddress(str), nil
+	case abi.StringTy:
+		return argumentString(value)
+	case abi.BytesTy:
+		str, err := argumentString(value)
+		if err != nil {
+			return nil, err
+		}
+		return hexutil.Decode(str)
+	case abi.FixedBytesTy, abi.FunctionTy:
+		return convertFixedBytes(t, value)
+	case abi.SliceTy, abi.ArrayTy:
+		return convertList(t, value)
+	case abi.TupleTy:
+		return convertTuple(t, value)
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", t.String())
+}
+
+func convertInteger(t abi.Type, value interface{}) (interface{}, error) {
+	str, err := argumentString(value)
+	if err != nil {
+		return nil, err
+	}
+	val, err := parseBigInt(str)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.T == abi.UintTy && val.Sign() < 0 {
+		return nil, fmt.Errorf("negative value %s", val)
+	}
+	if bitLen := val.BitLen(); (t.T == abi.UintTy && bitLen > t.Size) || (t.T == abi.IntTy && bitLen >= t.Size) {
+		// Two's complement lower bound is the only value using all the bits of a signed integer
+		minInt := new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), uint(t.Size-1)))
+		if t.T == abi.UintTy || val.Cmp(minInt) != 0 {
+			return nil, fmt.Errorf("value %s overflows %s", val, t.String())
+		}
+	}
+
+	// Integers up to 64 bits are packed from their native Go types
+	goType := t.GetType()
+	switch goType.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.ValueOf(val.Int64()).Convert(goType).Interface(), nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return reflect.ValueOf(val.Uint64()).Convert(goType).Interface(), nil
+	}
+	return val, nil
+}
+
+func convertBool(value interface{}) (interface{}, error) {
+	if b, ok := value.(bool); ok {
+		return b, nil
+	}
+	str, err := argumentString(value)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(strings.TrimSpace(str)) {
+	case "true", "1":
+		return true, nil
+	case "false", "0":
+		return false, nil
+	}
+	return nil, fmt.Errorf("invalid bool %q", str)
+}
+
+func convertFixedBytes(t abi.Type, value interface{}) (interface{}, error) {
+	str, err := argumentString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	// Values without 0x prefix are taken as raw text, e.g. short strings in bytes32
+	data := []byte(str)
+	if has0xPrefix(str) {
+		data, err = hexutil.Decode(str)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	array := reflect.New(t.GetType()).Elem()
+	if len(data) > array.Len() {
+		return nil, fmt.Errorf("value is %d bytes long, %s holds %d", len(data), t.String(), array.Len())
+	}
+	reflect.Copy(array, reflect.ValueOf(data))
+
+	return array.Interface(), nil
+}
+
+func convertList(t abi.Type, value interface{}) (interface{}, error) {
+	elems, err := argumentList(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var list reflect.Value
+	if t.T == abi.ArrayTy {
+		if len(elems) != t.Size {
+			return nil, fmt.Errorf("expected %d elements, got %d", t.Size, len(elems))
+		}
+		list = reflect.New(t.GetType()).Elem()
+	} else {
+		list = reflect.MakeSlice(t.GetType(), len(elems), len(elems))
+	}
+
+	for i, elem := range elems {
+		converted, err := convertArgument(*t.Elem, elem)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %v", i, err)
+		}
+		if err := setReflectValue(list.Index(i), converted); err != nil {
+			return nil, fmt.Errorf("element %d: %v", i, err)
+		}
+	}
+
+	return list.Interface(), nil
+}
+
+// convertTuple accepts either an object keyed by the component names or a positional list.
+func convertTuple(t abi.Type, value interface{}) (interface{}, error) {
+	if str, ok := value.(string); ok {
+		parsed, err := parseJSONArgument(str)
+		if err != nil {
+			return nil, err
+		}
+		value = parsed
+	}
+
+	elems := make([]interface{}, len(t.TupleElems))
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for i, name := range t.TupleRawNames {
+			elem, ok := v[name]
+			if !ok {
+				return nil, fmt.Errorf("missing component %s", name)
+			}
+			elems[i] = elem
+		}
+	case []interface{}:
+		if len(v) != len(t.TupleElems) {
+			return nil, fmt.Errorf("expected %d components, got %d", len(t.TupleElems), len(v))
+		}
+		copy(elems, v)
+	default:
+		return nil, fmt.Errorf("expected object or list, got %T", value)
+	}
+
+	tuple := reflect.New(t.GetType()).Elem()
+	for i, elem := range elems {
+		converted, err := convertArgument(*t.TupleElems[i], elem)
+		if err != nil {
+			return nil, fmt.Errorf("component %s: %v", t.TupleRawNames[i], err)
+		}
+		if err := setReflectValue(tuple.Field(i), converted); err != nil {
+			return nil, fmt.Errorf("component %s: %v", t.TupleRawNames[i], err)
+		}
+	}
+
+	return tuple.Interface(), nil
+}
+
+func setReflectValue(dst reflect.Value, value interface{}) error {
+	src := reflect.ValueOf(value)
+	if src.Type().AssignableTo(dst.Type()) {
+		dst.Set(src)
+		return nil
+	}
+	if src.Type().ConvertibleTo(dst.Type()) {
+		dst.Set(src.Convert(dst.Type()))
+		return nil
+	}
+	return fmt.Errorf("can't use %s as %s", src.Type(), dst.Type())
+}
+
+func argumentString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return fmt.Sprintf("%t", v), nil
+	}
+	return "", fmt.Errorf("expected a scalar value, got %T", value)
+}
+
+func argumentList(value interface{}) ([]interface{}, error) {
+	if str, ok := value.(string); ok {
+		parsed, err := parseJSONArgument(str)
+		if err != nil {
+			return nil, err
+		}
+		value = parsed
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a list, got %T", value)
+	}
+	return list, nil
+}
+
+func parseJSONArgument(str string) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(str)))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid JSON %q: %v", str, err)
+	}
+	return value, nil
+}
+
+// parseBigInt parses decimal and 0x prefixed hexadecimal integers, both optionally negative.
+func parseBigInt(str string) (*big.Int, error) {
+	str = strings.TrimSpace(str)
+	negative := strings.HasPrefix(str, "-")
+	digits := strings.TrimPrefix(str, "-")
+
+	base := 10
+	if has0xPrefix(digits) {
+		base = 16
+		digits = digits[2:]
+	}
+
+	val, ok := new(big.Int).SetString(digits, base)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q", str)
+	}
+	if negative {
+		val.Neg(val)
+	}
+	return val, nil
+}
+
+func has0xPrefix(str string) bool {
+	return len(str) >= 2 && str[0] == '0' && (str[1] == 'x' || str[1] == 'X')
+}
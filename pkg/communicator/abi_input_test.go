@@ -0,0 +1,70 @@
+package communicator
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+const (
+	// Contract ABI with struct, array and fixed size integer arguments for testing
+	complexContractABI = `[{"inputs":[{"components":[{"internalType":"address","name":"owner","type":"address"},{"internalType":"uint96","name":"amount","type":"uint96"},{"internalType":"bytes","name":"data","type":"bytes"}],"internalType":"struct Vault.Deposit","name":"deposit","type":"tuple"},{"internalType":"int8[2]","name":"offsets","type":"int8[2]"},{"internalType":"uint256[]","name":"ids","type":"uint256[]"},{"internalType":"bool","name":"flag","type":"bool"},{"internalType":"bytes4","name":"tag","type":"bytes4"}],"name":"store","outputs":[{"components":[{"internalType":"address","name":"owner","type":"address"},{"internalType":"uint96","name":"amount","type":"uint96"},{"internalType":"bytes","name":"data","type":"bytes"}],"internalType":"struct Vault.Deposit","name":"","type":"tuple"},{"internalType":"int8[2]","name":"","type":"int8[2]"},{"internalType":"uint256[]","name":"ids","type":"uint256[]"},{"internalType":"bool","name":"","type":"bool"},{"internalType":"bytes4","name":"tag","type":"bytes4"}],"stateMutability":"nonpayable","type":"function"}]`
+)
+
+func TestGetCallData(t *testing.T) {
+	var input Arguments
+	err := json.Unmarshal([]byte(`[
+		{"owner": "0x9491A3757A98e53BE0d1c14834a6e2Da0B4Dc527", "amount": "0x2a", "data": "0xdeadbeef"},
+		[-1, 127],
+		"[1, \"0x10\", 300]",
+		true,
+		"0x01020304"
+	]`), &input)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal input: %v", err)
+	}
+
+	callData, method, err := getCallData(context.Background(), parseTestABI(t, complexContractABI), "store", input)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if method.Name != "store" {
+		t.Errorf("Expected method 'store', got '%s'", method.Name)
+	}
+
+	unpacked, err := method.Inputs.Unpack(callData[4:])
+	if err != nil {
+		t.Fatalf("Failed to unpack call data: %v", err)
+	}
+	if len(unpacked) != 5 {
+		t.Fatalf("Expected 5 arguments, got %d", len(unpacked))
+	}
+	if offsets := unpacked[1].([2]int8); offsets != [2]int8{-1, 127} {
+		t.Errorf("Unexpected offsets %v", offsets)
+	}
+}
+
+func TestGetCallDataInvalidInput(t *testing.T) {
+	_, _, err := getCallData(context.Background(), parseTestABI(t, complexContractABI), "store", []string{
+		`{"owner": "0x9491A3757A98e53BE0d1c14834a6e2Da0B4Dc527", "amount": "1", "data": "0x"}`,
+		"[-1, 128]",
+		"[]",
+		"true",
+		"0x01",
+	})
+	if err == nil {
+		t.Fatalf("Expected int8 overflow error")
+	}
+}
+
+func parseTestABI(t *testing.T, contractABI string) abi.ABI {
+	t.Helper()
+	parsedABI, err := abi.JSON(strings.NewReader(contractABI))
+	if err != nil {
+		t.Fatalf("Failed to parse ABI: %v", err)
+	}
+	return parsedABI
+}
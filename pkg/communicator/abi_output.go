@@ -0,0 +1,109 @@
+package communicator
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// unpackToJSON unpacks the ABI encoded data and converts the values with toJSONValue, keyed by the
+// argument names. Unnamed arguments are named by their position with the given prefix.
+func unpackToJSON(args abi.Arguments, data []byte, unnamedPrefix string) (map[string]interface{}, error) {
+	values, err := args.Unpack(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return argumentsToJSON(args, values, unnamedPrefix), nil
+}
+
+func argumentsToJSON(args abi.Arguments, values []interface{}, unnamedPrefix string) map[string]interface{} {
+	decoded := make(map[string]interface{}, len(args))
+	for i, arg := range args {
+		if i >= len(values) {
+			break
+		}
+		name := arg.Name
+		if name == "" {
+			name = fmt.Sprintf("%s_%d", unnamedPrefix, i)
+		}
+		decoded[name] = toJSONValue(arg.Type, values[i])
+	}
+
+	return decoded
+}
+
+// toJSONValue converts a value unpacked by the abi package into its JSON friendly form: integers as
+// decimal strings, bytes as 0x prefixed hex, arrays as lists and tuples as objects keyed by the
+// component names.
+func toJSONValue(t abi.Type, value interface{}) interface{} {
+	// Indexed event arguments of dynamic types are only available as their hash
+	if hash, ok := value.(common.Hash); ok && t.T != abi.FixedBytesTy {
+		return hash.Hex()
+	}
+
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		if val, ok := value.(*big.Int); ok {
+			return val.String()
+		}
+		return fmt.Sprintf("%d", value)
+	case abi.BoolTy, abi.StringTy:
+		return value
+	case abi.AddressTy:
+		if address, ok := value.(common.Address); ok {
+			return address.Hex()
+		}
+		return value
+	case abi.BytesTy:
+		if data, ok := value.([]byte); ok {
+			return hexutil.Encode(data)
+		}
+		return value
+	case abi.FixedBytesTy, abi.FunctionTy, abi.HashTy:
+		return fixedBytesToHex(value)
+	case abi.SliceTy, abi.ArrayTy:
+		list := reflect.ValueOf(value)
+		if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+			return value
+		}
+		converted := make([]interface{}, 0, list.Len())
+		for i := 0; i < list.Len(); i++ {
+			converted = append(converted, toJSONValue(*t.Elem, list.Index(i).Interface()))
+		}
+		return converted
+	case abi.TupleTy:
+		tuple := reflect.ValueOf(value)
+		if tuple.Kind() == reflect.Ptr {
+			tuple = tuple.Elem()
+		}
+		if tuple.Kind() != reflect.Struct {
+			return value
+		}
+		converted := make(map[string]interface{}, len(t.TupleElems))
+		for i, elem := range t.TupleElems {
+			name := t.TupleRawNames[i]
+			if name == "" {
+				name = fmt.Sprintf("component_%d", i)
+			}
+			converted[name] = toJSONValue(*elem, tuple.Field(i).Interface())
+		}
+		return converted
+	}
+
+	return value
+}
+
+func fixedBytesToHex(value interface{}) interface{} {
+	array := reflect.ValueOf(value)
+	if array.Kind() != reflect.Array || array.Type().Elem().Kind() != reflect.Uint8 {
+		return value
+	}
+	data := make([]byte, array.Len())
+	reflect.Copy(reflect.ValueOf(data), array)
+	return hexutil.Encode(data)
+}
@@ -0,0 +1,35 @@
+package communicator
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestParseResult(t *testing.T) {
+	// Outputs of the store method mirror its inputs, so the packed input can be parsed as result
+	callData, method, err := getCallData(context.Background(), parseTestABI(t, complexContractABI), "store", []string{
+		`{"owner": "0x9491A3757A98e53BE0d1c14834a6e2Da0B4Dc527", "amount": "42", "data": "0xdeadbeef"}`,
+		"[-1, 127]",
+		"[1, 16]",
+		"true",
+		"0x01020304",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	decoded, err := parseResult(context.Background(), method, callData[4:])
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatalf("Failed to marshal result: %v", err)
+	}
+	expected := `{"ids":["1","16"],"output_0":{"amount":"42","data":"0xdeadbeef","owner":"0x9491A3757A98e53BE0d1c14834a6e2Da0B4Dc527"},"output_1":["-1","127"],"output_3":true,"tag":"0x01020304"}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+}
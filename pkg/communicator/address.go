@@ -0,0 +1,157 @@
+package communicator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const (
+	defaultAddressPageSize       = 25
+	defaultAddressNumberOfBlocks = 1000
+)
+
+type GetAddressRequest struct {
+	Address string `json:"address"`
+
+	// Block number to get the balance at, only the latest balance returned if zero
+	BlockNumber int64 `json:"block_number"`
+
+	// Block number to start the transaction scan from (reversed order), latest block if zero
+	StartBlock int64 `json:"start_block"`
+
+	// Number of transactions to collect before the scan stops
+	PageSize int64 `json:"page_size"`
+
+	// Maximum number of blocks to scan for transactions
+	NumberOfBlocks int64 `json:"number_of_blocks"`
+}
+
+type GetAddressResponse struct {
+	Address        string        `json:"address"`
+	Balance        string        `json:"balance"`
+	BalanceAtBlock string        `json:"balance_at_block,omitempty"`
+	Nonce          uint64        `json:"nonce"`
+	IsContract     bool          `json:"is_contract"`
+	CodeSize       int           `json:"code_size"`
+	CodeHash       string        `json:"code_hash,omitempty"`
+	Transactions   []Transaction `json:"transactions"`
+
+	// Block number to continue the transaction scan from, zero if the scan reached the genesis block
+	NextBlock int64 `json:"next_block"`
+}
+
+func GetAddress(ctx context.Context, req GetAddressRequest) (GetAddressResponse, error) {
+	return getAddress(ctx, req)
+}
+
+func getAddress(ctx context.Context, req GetAddressRequest) (GetAddressResponse, error) {
+	if !common.IsHexAddress(req.Address) {
+		return GetAddressResponse{}, fmt.Errorf("invalid address: %s", req.Address)
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = defaultAddressPageSize
+	}
+	if req.NumberOfBlocks <= 0 {
+		req.NumberOfBlocks = defaultAddressNumberOfBlocks
+	}
+	address := common.HexToAddress(req.Address)
+
+	client, err := ethclient.DialContext(ctx, GetNodeAddress(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to connect to Ethereum client", slog.Any("err", err))
+		return GetAddressResponse{}, err
+	}
+
+	balance, err := client.BalanceAt(ctx, address, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get balance", slog.Any("address", req.Address), slog.Any("err", err))
+		return GetAddressResponse{}, fmt.Errorf("failed to get balance: %v", err)
+	}
+
+	nonce, err := client.NonceAt(ctx, address, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get nonce", slog.Any("address", req.Address), slog.Any("err", err))
+		return GetAddressResponse{}, fmt.Errorf("failed to get nonce: %v", err)
+	}
+
+	code, err := client.CodeAt(ctx, address, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get code", slog.Any("address", req.Address), slog.Any("err", err))
+		return GetAddressResponse{}, fmt.Errorf("failed to get code: %v", err)
+	}
+
+	response := GetAddressResponse{
+		Address:      address.Hex(),
+		Balance:      balance.String(),
+		Nonce:        nonce,
+		IsContract:   len(code) > 0,
+		CodeSize:     len(code),
+		Transactions: []Transaction{},
+	}
+	if len(code) > 0 {
+		response.CodeHash = crypto.Keccak256Hash(code).Hex()
+	}
+
+	if req.BlockNumber > 0 {
+		balanceAtBlock, err := client.BalanceAt(ctx, address, big.NewInt(req.BlockNumber))
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to get balance at block", slog.Any("address", req.Address), slog.Any("block_number", req.BlockNumber), slog.Any("err", err))
+			return GetAddressResponse{}, fmt.Errorf("failed to get balance at block %d: %v", req.BlockNumber, err)
+		}
+		response.BalanceAtBlock = balanceAtBlock.String()
+	}
+
+	if req.StartBlock == 0 {
+		blockNumber, err := client.BlockNumber(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to get latest block number", slog.Any("err", err))
+			return GetAddressResponse{}, err
+		}
+		req.StartBlock = int64(blockNumber)
+	}
+
+	// Walk the blocks backwards the same way as getLatestNBlock, the current block is
+	// always finished so the next page can continue from the following one
+	for i := int64(0); i < req.NumberOfBlocks; i++ {
+		nextIndex := req.StartBlock - i
+		if nextIndex < 1 {
+			response.NextBlock = 0
+			return response, nil
+		}
+		response.NextBlock = nextIndex - 1
+
+		block, err := client.BlockByNumber(ctx, big.NewInt(nextIndex))
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to retrieve block", slog.Any("block_number", nextIndex), slog.Any("err", err))
+			return GetAddressResponse{}, err
+		}
+
+		for j, transaction := range block.Transactions() {
+			parsedTransaction, err := parseTransaction(transaction, block.Number().String(), int64(j))
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to parse transaction", slog.Any("block_number", nextIndex), slog.Any("transaction_index", j), slog.Any("err", err))
+				return GetAddressResponse{}, err
+			}
+			if isTransactionOfAddress(parsedTransaction, address) {
+				response.Transactions = append(response.Transactions, parsedTransaction)
+			}
+		}
+
+		if int64(len(response.Transactions)) >= req.PageSize {
+			break
+		}
+	}
+
+	return response, nil
+}
+
+func isTransactionOfAddress(transaction Transaction, address common.Address) bool {
+	return strings.EqualFold(transaction.From, address.Hex()) || strings.EqualFold(transaction.To, address.Hex())
+}
@@ -0,0 +1,81 @@
+package communicator
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestGetAddress(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	other, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient := common.HexToAddress("0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC")
+
+	// The address sends in blocks 1, 3 and 5, block 2 and 4 only have transactions of others
+	chain := newTestChain(t,
+		[]*types.Transaction{newTestTransaction(t, key, 0, recipient)},
+		[]*types.Transaction{newTestTransaction(t, other, 0, recipient)},
+		[]*types.Transaction{newTestTransaction(t, other, 1, recipient), newTestTransaction(t, key, 1, recipient)},
+		[]*types.Transaction{},
+		[]*types.Transaction{newTestTransaction(t, key, 2, recipient)},
+	)
+	chain.balances[address] = big.NewInt(1_000_000)
+	chain.nonces[address] = 3
+	ctx := newTestNode(t, map[string]interface{}{"eth": chain})
+
+	resp, err := getAddress(ctx, GetAddressRequest{
+		Address:  address.Hex(),
+		PageSize: 2,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if resp.Balance != "1000000" || resp.Nonce != 3 || resp.IsContract || resp.CodeSize != 0 {
+		t.Errorf("Unexpected account state %+v", resp)
+	}
+	if len(resp.Transactions) != 2 || resp.Transactions[0].Nonce != 2 || resp.Transactions[1].Nonce != 1 {
+		t.Fatalf("Expected the transactions of block 5 and 3, got %+v", resp.Transactions)
+	}
+	if resp.Transactions[0].BlockNumber != "5" || resp.Transactions[1].BlockNumber != "3" {
+		t.Errorf("Expected the transactions of block 5 and 3, got blocks %s and %s", resp.Transactions[0].BlockNumber, resp.Transactions[1].BlockNumber)
+	}
+	if resp.NextBlock != 2 {
+		t.Errorf("Expected the next page to start at block 2, got %d", resp.NextBlock)
+	}
+
+	// The next page continues below the last scanned block and ends at the genesis block
+	resp, err = getAddress(ctx, GetAddressRequest{
+		Address:    address.Hex(),
+		PageSize:   2,
+		StartBlock: resp.NextBlock,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(resp.Transactions) != 1 || resp.Transactions[0].Nonce != 0 || resp.Transactions[0].BlockNumber != "1" {
+		t.Errorf("Expected the transaction of block 1, got %+v", resp.Transactions)
+	}
+	if resp.NextBlock != 0 {
+		t.Errorf("Expected the scan to reach the genesis block, got next block %d", resp.NextBlock)
+	}
+
+	// Contracts are detected by their code
+	chain.codes[recipient] = []byte{0x60, 0x00}
+	resp, err = getAddress(ctx, GetAddressRequest{Address: recipient.Hex(), NumberOfBlocks: 1})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !resp.IsContract || resp.CodeSize != 2 || resp.CodeHash != crypto.Keccak256Hash([]byte{0x60, 0x00}).Hex() {
+		t.Errorf("Expected a contract with 2 bytes of code, got %+v", resp)
+	}
+}
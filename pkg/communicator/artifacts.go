@@ -0,0 +1,657 @@
+package communicator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const DefaultArtifactPollInterval = 2 * time.Second
+
+var (
+	registeredArtifactRegistryMu sync.RWMutex
+	registeredArtifactRegistry   *ArtifactRegistry
+)
+
+// Directories never holding contract artifacts, build-info files are huge and skipped as well
+var skippedArtifactDirs = map[string]struct{}{
+	"build-info":   {},
+	"node_modules": {},
+	"cache":        {},
+	".git":         {},
+}
+
+// ArtifactRegistry loads the compiled contracts of Hardhat (artifacts/, hardhat-deploy deployments/),
+// Foundry (out/, broadcast/) and Truffle (build/contracts/) projects. Contracts are found by their
+// deployment files or by matching their deployed bytecode against the code on chain.
+type ArtifactRegistry struct {
+	path         string
+	pollInterval time.Duration
+
+	mu          sync.RWMutex
+	fingerprint uint64
+	generation  int
+	artifacts   []*artifact
+	deployments map[uint64]map[common.Address]*artifact // chain ID -> address -> artifact
+	matches     map[string]*artifact                    // node address/contract address -> artifact, nil if nothing matched
+	chainIDs    map[string]uint64                       // node address -> chain ID
+
+	// Build info of the loaded generation, read on first use
+	buildInfos           *buildInfoIndex
+	buildInfosGeneration int
+}
+
+type artifact struct {
+	name       string
+	sourceName string
+	path       string
+	abi        abi.ABI
+
+	// Creation bytecode as hex, it may contain library placeholders
+	bytecode string
+
+	// Deployed bytecode without metadata, wildcard marks linked libraries and immutables
+	code     []byte
+	wildcard []bool
+
+	// Only in Foundry artifacts with the storageLayout extra output, the build info has it otherwise
+	storageLayout *StorageLayout
+}
+
+type GetArtifactsResponse struct {
+	Path      string             `json:"path"`
+	Contracts []ArtifactContract `json:"contracts"`
+}
+
+type ArtifactContract struct {
+	Name        string               `json:"name"`
+	SourceName  string               `json:"source_name,omitempty"`
+	Path        string               `json:"path"`
+	Deployments []ArtifactDeployment `json:"deployments"`
+}
+
+type ArtifactDeployment struct {
+	ChainID uint64 `json:"chain_id"`
+	Address string `json:"address"`
+}
+
+// artifactFile holds the fields of every supported artifact format
+type artifactFile struct {
+	ContractName     string          `json:"contractName"`
+	SourceName       string          `json:"sourceName"`
+	ABI              json.RawMessage `json:"abi"`
+	Bytecode         json.RawMessage `json:"bytecode"`         // Hex string, or object for Foundry
+	DeployedBytecode json.RawMessage `json:"deployedBytecode"` // Hex string, or object for Foundry
+	StorageLayout    *StorageLayout  `json:"storageLayout"`
+
+	// hardhat-deploy deployment
+	Address string `json:"address"`
+
+	// Truffle deployments keyed by network ID
+	Networks map[string]struct {
+		Address string `json:"address"`
+	} `json:"networks"`
+
+	// Foundry broadcast
+	Chain        uint64 `json:"chain"`
+	Transactions []struct {
+		TransactionType string `json:"transactionType"`
+		ContractName    string `json:"contractName"`
+		ContractAddress string `json:"contractAddress"`
+	} `json:"transactions"`
+}
+
+type broadcastDeployment struct {
+	chainID      uint64
+	contractName string
+	address      common.Address
+}
+
+func NewArtifactRegistry(path string, pollInterval time.Duration) *ArtifactRegistry {
+	if pollInterval <= 0 {
+		pollInterval = DefaultArtifactPollInterval
+	}
+	return &ArtifactRegistry{
+		path:         path,
+		pollInterval: pollInterval,
+		deployments:  make(map[uint64]map[common.Address]*artifact),
+		matches:      make(map[string]*artifact),
+		chainIDs:     make(map[string]uint64),
+	}
+}
+
+// SetArtifactRegistry registers the artifact registry, the decoders fall back to its ABIs.
+func SetArtifactRegistry(registry *ArtifactRegistry) {
+	registeredArtifactRegistryMu.Lock()
+	defer registeredArtifactRegistryMu.Unlock()
+	registeredArtifactRegistry = registry
+}
+
+func getArtifactRegistry() *ArtifactRegistry {
+	registeredArtifactRegistryMu.RLock()
+	defer registeredArtifactRegistryMu.RUnlock()
+	return registeredArtifactRegistry
+}
+
+// Run loads the artifacts and reloads them whenever a file changes until the context is cancelled.
+func (r *ArtifactRegistry) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.reloadIfChanged(ctx); err != nil {
+			slog.ErrorContext(ctx, "Failed to load artifacts", slog.Any("path", r.path), slog.Any("err", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *ArtifactRegistry) reloadIfChanged(ctx context.Context) error {
+	fingerprint, err := r.computeFingerprint()
+	if err != nil {
+		return err
+	}
+	r.mu.RLock()
+	unchanged := r.generation > 0 && fingerprint == r.fingerprint
+	r.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	if err := r.Load(ctx); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.fingerprint = fingerprint
+	r.mu.Unlock()
+
+	return nil
+}
+
+// computeFingerprint hashes the names, sizes and modification times of the artifact files.
+func (r *ArtifactRegistry) computeFingerprint() (uint64, error) {
+	hash := fnv.New64a()
+	err := r.walk(func(path string, info fs.FileInfo) error {
+		_, err := fmt.Fprintf(hash, "%s:%d:%d;", path, info.Size(), info.ModTime().UnixNano())
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return hash.Sum64(), nil
+}
+
+// Load reads every artifact under the path and replaces the previously loaded ones.
+func (r *ArtifactRegistry) Load(ctx context.Context) error {
+	var (
+		artifacts  []*artifact
+		broadcasts []broadcastDeployment
+	)
+	deployments := make(map[uint64]map[common.Address]*artifact)
+	addDeployment := func(chainID uint64, address common.Address, loaded *artifact) {
+		if _, ok := deployments[chainID]; !ok {
+			deployments[chainID] = make(map[common.Address]*artifact)
+		}
+		deployments[chainID][address] = loaded
+	}
+
+	err := r.walk(func(path string, info fs.FileInfo) error {
+		if filepath.Ext(path) != ".json" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var file artifactFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			// Not every JSON file is an artifact
+			slog.DebugContext(ctx, "Skipping JSON file", slog.Any("path", path), slog.Any("err", err))
+			return nil
+		}
+
+		for _, transaction := range file.Transactions {
+			if !isCreateOp(transaction.TransactionType) || !common.IsHexAddress(transaction.ContractAddress) {
+				continue
+			}
+			broadcasts = append(broadcasts, broadcastDeployment{
+				chainID:      broadcastChainID(path, file.Chain),
+				contractName: transaction.ContractName,
+				address:      common.HexToAddress(transaction.ContractAddress),
+			})
+		}
+
+		loaded, ok, err := parseArtifactFile(path, file)
+		if err != nil {
+			slog.InfoContext(ctx, "Skipping invalid artifact", slog.Any("path", path), slog.Any("err", err))
+			return nil
+		}
+		if !ok {
+			return nil
+		}
+		artifacts = append(artifacts, loaded)
+		getSignatureDatabase().addABI(loaded.abi)
+
+		if common.IsHexAddress(file.Address) {
+			if chainID, ok := hardhatDeployChainID(path); ok {
+				addDeployment(chainID, common.HexToAddress(file.Address), loaded)
+			}
+		}
+		for networkID, network := range file.Networks {
+			chainID, err := strconv.ParseUint(networkID, 10, 64)
+			if err != nil || !common.IsHexAddress(network.Address) {
+				continue
+			}
+			addDeployment(chainID, common.HexToAddress(network.Address), loaded)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Broadcasts only name the contracts, their ABI comes from the compiled artifacts
+	for _, broadcast := range broadcasts {
+		for _, loaded := range artifacts {
+			if loaded.name == broadcast.contractName {
+				addDeployment(broadcast.chainID, broadcast.address, loaded)
+				break
+			}
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.artifacts = artifacts
+	r.deployments = deployments
+	r.matches = make(map[string]*artifact)
+	r.generation++
+	slog.InfoContext(ctx, "Loaded artifacts", slog.Any("path", r.path), slog.Any("contracts", len(artifacts)))
+
+	return nil
+}
+
+// walk calls fn with every file which can hold artifacts or deployment information, in lexical order.
+func (r *ArtifactRegistry) walk(fn func(path string, info fs.FileInfo) error) error {
+	return filepath.WalkDir(r.path, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if _, ok := skippedArtifactDirs[entry.Name()]; ok {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(entry.Name(), ".dbg.json") {
+			return nil
+		}
+		if filepath.Ext(path) != ".json" && entry.Name() != ".chainId" {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		return fn(path, info)
+	})
+}
+
+// ContractABI returns the ABI of the contract deployed at the address on the node of the context.
+func (r *ArtifactRegistry) ContractABI(ctx context.Context, address common.Address) *abi.ABI {
+	loaded := r.artifactAt(ctx, address)
+	if loaded == nil {
+		return nil
+	}
+	return &loaded.abi
+}
+
+// StorageLayout returns the storage layout of the contract deployed at the address on the node of the context.
+func (r *ArtifactRegistry) StorageLayout(ctx context.Context, address common.Address) *StorageLayout {
+	loaded := r.artifactAt(ctx, address)
+	if loaded == nil {
+		return nil
+	}
+	if loaded.storageLayout != nil {
+		return loaded.storageLayout
+	}
+	if contract := r.buildInfo(ctx, loaded); contract != nil {
+		return contract.storageLayout
+	}
+	return nil
+}
+
+// SourceArtifact returns the source map and sources of the contract deployed at the address on the node
+// of the context, they are only available from the build info.
+func (r *ArtifactRegistry) SourceArtifact(ctx context.Context, address common.Address) *SourceArtifact {
+	loaded := r.artifactAt(ctx, address)
+	if loaded == nil {
+		return nil
+	}
+	if contract := r.buildInfo(ctx, loaded); contract != nil {
+		return contract.sourceArtifact
+	}
+	return nil
+}
+
+func (r *ArtifactRegistry) artifactAt(ctx context.Context, address common.Address) *artifact {
+	nodeAddress := GetNodeAddress(ctx)
+	key := nodeAddress + "/" + address.Hex()
+
+	r.mu.RLock()
+	matched, ok := r.matches[key]
+	chainID, chainIDOK := r.chainIDs[nodeAddress]
+	generation := r.generation
+	artifacts := r.artifacts
+	r.mu.RUnlock()
+	if ok {
+		return matched
+	}
+	if len(artifacts) == 0 {
+		return nil
+	}
+
+	client, err := ethclient.DialContext(ctx, nodeAddress)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to connect to Ethereum client", slog.Any("err", err))
+		return nil
+	}
+	defer client.Close()
+
+	if !chainIDOK {
+		id, err := client.ChainID(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to get chain ID", slog.Any("err", err))
+			return nil
+		}
+		chainID = id.Uint64()
+		r.mu.Lock()
+		r.chainIDs[nodeAddress] = chainID
+		r.mu.Unlock()
+	}
+
+	r.mu.RLock()
+	deployed, ok := r.deployments[chainID][address]
+	r.mu.RUnlock()
+	if ok {
+		return deployed
+	}
+
+	code, err := client.CodeAt(ctx, address, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get code", slog.Any("address", address.Hex()), slog.Any("err", err))
+		return nil
+	}
+	if len(code) == 0 {
+		// Accounts without code aren't cached, a contract might be deployed to them later
+		return nil
+	}
+	code = stripMetadata(code)
+	for _, candidate := range artifacts {
+		if candidate.matches(code) {
+			matched = candidate
+			break
+		}
+	}
+
+	r.mu.Lock()
+	if r.generation == generation {
+		r.matches[key] = matched
+	}
+	r.mu.Unlock()
+
+	return matched
+}
+
+// forgetNode drops the chain ID and the matched contracts of the node, they are looked up again on next use.
+func (r *ArtifactRegistry) forgetNode(nodeAddress string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.chainIDs, nodeAddress)
+	for key := range r.matches {
+		if strings.HasPrefix(key, nodeAddress+"/") {
+			delete(r.matches, key)
+		}
+	}
+}
+
+// artifactByName returns the artifact with creation bytecode by contract name or fully qualified name
+// (e.g. contracts/Token.sol:Token). Artifacts of the same contract found in multiple files are equal.
+func (r *ArtifactRegistry) artifactByName(name string) (*artifact, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found *artifact
+	for _, loaded := range r.artifacts {
+		if loaded.bytecode == "" || strings.TrimPrefix(loaded.bytecode, "0x") == "" {
+			continue
+		}
+		if loaded.name != name && loaded.sourceName+":"+loaded.name != name {
+			continue
+		}
+		if found != nil && found.bytecode != loaded.bytecode {
+			return nil, fmt.Errorf("artifact name %s is ambiguous: %s and %s, use the fully qualified name", name, found.path, loaded.path)
+		}
+		if found == nil {
+			found = loaded
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no artifact with bytecode found for %s", name)
+	}
+	return found, nil
+}
+
+func (r *ArtifactRegistry) contracts() []ArtifactContract {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	deployments := make(map[*artifact][]ArtifactDeployment)
+	for chainID, addresses := range r.deployments {
+		for address, deployed := range addresses {
+			deployments[deployed] = append(deployments[deployed], ArtifactDeployment{
+				ChainID: chainID,
+				Address: address.Hex(),
+			})
+		}
+	}
+
+	contracts := make([]ArtifactContract, 0, len(r.artifacts))
+	for _, loaded := range r.artifacts {
+		contract := ArtifactContract{
+			Name:        loaded.name,
+			SourceName:  loaded.sourceName,
+			Path:        loaded.path,
+			Deployments: deployments[loaded],
+		}
+		if contract.Deployments == nil {
+			contract.Deployments = []ArtifactDeployment{}
+		}
+		sort.Slice(contract.Deployments, func(i, j int) bool {
+			if contract.Deployments[i].ChainID != contract.Deployments[j].ChainID {
+				return contract.Deployments[i].ChainID < contract.Deployments[j].ChainID
+			}
+			return contract.Deployments[i].Address < contract.Deployments[j].Address
+		})
+		contracts = append(contracts, contract)
+	}
+
+	return contracts
+}
+
+func GetArtifacts(ctx context.Context) (GetArtifactsResponse, error) {
+	return getArtifacts(ctx)
+}
+
+func getArtifacts(_ context.Context) (GetArtifactsResponse, error) {
+	registry := getArtifactRegistry()
+	if registry == nil {
+		return GetArtifactsResponse{Contracts: []ArtifactContract{}}, nil
+	}
+	return GetArtifactsResponse{
+		Path:      registry.path,
+		Contracts: registry.contracts(),
+	}, nil
+}
+
+// parseArtifactFile parses the files having an ABI, ok is false for every other JSON file.
+func parseArtifactFile(path string, file artifactFile) (*artifact, bool, error) {
+	if len(file.ABI) == 0 || file.ABI[0] != '[' {
+		return nil, false, nil
+	}
+	parsedABI, err := abi.JSON(strings.NewReader(string(file.ABI)))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to parse ABI: %v", err)
+	}
+
+	loaded := &artifact{
+		name:          file.ContractName,
+		sourceName:    file.SourceName,
+		path:          path,
+		abi:           parsedABI,
+		storageLayout: file.StorageLayout,
+	}
+	if loaded.name == "" {
+		loaded.name = strings.TrimSuffix(filepath.Base(path), ".json")
+	}
+
+	loaded.bytecode, err = artifactBytecode(file.Bytecode)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid bytecode: %v", err)
+	}
+	deployedBytecode, err := artifactBytecode(file.DeployedBytecode)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid deployed bytecode: %v", err)
+	}
+	loaded.code, loaded.wildcard, err = parseDeployedBytecode(deployedBytecode)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return loaded, true, nil
+}
+
+// artifactBytecode returns the hex bytecode, Foundry wraps it into an object.
+func artifactBytecode(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 {
+		return "", nil
+	}
+	var bytecode string
+	if err := json.Unmarshal(raw, &bytecode); err == nil {
+		return bytecode, nil
+	}
+	var foundryBytecode struct {
+		Object string `json:"object"`
+	}
+	if err := json.Unmarshal(raw, &foundryBytecode); err != nil {
+		return "", err
+	}
+	return foundryBytecode.Object, nil
+}
+
+// parseDeployedBytecode decodes the bytecode and marks the bytes differing between deployments:
+// library placeholders and zero PUSH32 immediates, which are the placeholders of immutables.
+func parseDeployedBytecode(deployedBytecode string) ([]byte, []bool, error) {
+	hexCode := strings.TrimPrefix(deployedBytecode, "0x")
+	if hexCode == "" {
+		return nil, nil, nil
+	}
+
+	var libraries [][]int
+	for _, location := range libraryPlaceholder.FindAllStringIndex(hexCode, -1) {
+		libraries = append(libraries, []int{location[0] / 2, location[1] / 2})
+	}
+	hexCode = libraryPlaceholder.ReplaceAllString(hexCode, strings.Repeat("0", 40))
+	code := common.FromHex(hexCode)
+	if len(code)*2 != len(hexCode) {
+		return nil, nil, fmt.Errorf("invalid deployed bytecode")
+	}
+	code = stripMetadata(code)
+
+	wildcard := make([]bool, len(code))
+	for _, library := range libraries {
+		for i := library[0]; i < library[1] && i < len(code); i++ {
+			wildcard[i] = true
+		}
+	}
+	for pc := 0; pc < len(code); pc++ {
+		op := code[pc]
+		if op < 0x60 || op > 0x7f {
+			continue
+		}
+		size := int(op - 0x5f)
+		if op == 0x7f && pc+size < len(code) && new(big.Int).SetBytes(code[pc+1:pc+1+size]).Sign() == 0 {
+			for i := pc + 1; i <= pc+size; i++ {
+				wildcard[i] = true
+			}
+		}
+		pc += size
+	}
+
+	return code, wildcard, nil
+}
+
+func (a *artifact) matches(code []byte) bool {
+	if len(a.code) == 0 || len(a.code) != len(code) {
+		return false
+	}
+	for i := range code {
+		if code[i] != a.code[i] && !a.wildcard[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// stripMetadata removes the CBOR encoded compiler metadata, its length is stored in the last two bytes.
+func stripMetadata(code []byte) []byte {
+	if len(code) < 2 {
+		return code
+	}
+	length := int(code[len(code)-2])<<8 | int(code[len(code)-1])
+	start := len(code) - 2 - length
+	if length == 0 || start < 0 || code[start] < 0xa1 || code[start] > 0xb7 {
+		return code
+	}
+	return code[:start]
+}
+
+// hardhatDeployChainID reads the .chainId file hardhat-deploy writes next to the deployments.
+func hardhatDeployChainID(path string) (uint64, bool) {
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(path), ".chainId"))
+	if err != nil {
+		return 0, false
+	}
+	chainID, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	return chainID, err == nil
+}
+
+// broadcastChainID falls back to the directory name of broadcast/<script>/<chain ID>/run-latest.json.
+func broadcastChainID(path string, chain uint64) uint64 {
+	if chain != 0 {
+		return chain
+	}
+	chainID, _ := strconv.ParseUint(filepath.Base(filepath.Dir(path)), 10, 64)
+	return chainID
+}
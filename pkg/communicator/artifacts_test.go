@@ -0,0 +1,132 @@
+package communicator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestArtifactRegistry(t *testing.T) {
+	root := t.TempDir()
+	abiJSON := strings.ReplaceAll(contractABI, "\n", "")
+	storageLayout := `{"storage":[{"label":"_totalSupply","offset":0,"slot":"2","type":"t_uint256"}],"types":{"t_uint256":{"encoding":"inplace","label":"uint256","numberOfBytes":"32"}}}`
+	files := map[string]string{
+		// Hardhat artifact, the debug file next to it is skipped
+		"artifacts/contracts/Token.sol/Token.json":     `{"_format":"hh-sol-artifact-1","contractName":"Token","sourceName":"contracts/Token.sol","abi":` + abiJSON + `,"deployedBytecode":"0x6000"}`,
+		"artifacts/contracts/Token.sol/Token.dbg.json": `{"_format":"hh-sol-dbg-1","buildInfo":"../../build-info/1.json"}`,
+		"artifacts/build-info/1.json":                  `{"input":{"sources":{"contracts/Token.sol":{"content":"contract Token {}"}}},"output":{"contracts":{"contracts/Token.sol":{"Token":{"storageLayout":` + storageLayout + `,"evm":{"deployedBytecode":{"object":"6000","sourceMap":"0:17:0"}}}}},"sources":{"contracts/Token.sol":{"id":0}}}}`,
+		// hardhat-deploy deployment
+		"deployments/localhost/.chainId":   "31337",
+		"deployments/localhost/Token.json": `{"address":"0x5FbDB2315678afecb367f032d93F642f64180aa3","abi":` + abiJSON + `}`,
+		// Foundry artifact and broadcast
+		"out/Vault.sol/Vault.json":                     `{"abi":` + complexContractABI + `,"deployedBytecode":{"object":"0x6001","sourceMap":""},"storageLayout":{"storage":[],"types":null}}`,
+		"broadcast/Deploy.s.sol/31337/run-latest.json": `{"transactions":[{"transactionType":"CREATE","contractName":"Vault","contractAddress":"0xe7f1725e7734ce288f8367e1bb143e90bb3f0512"}],"chain":31337}`,
+		"build/contracts/Migrations.json":              `{"contractName":"Migrations","abi":[],"deployedBytecode":"0x","networks":{"5777":{"address":"0x9fE46736679d2D9a65F0992F2272dE9f3c7fa6e0"}}}`,
+		"package.json":                                 `{"name":"project"}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	registry := NewArtifactRegistry(root, 0)
+	if err := registry.reloadIfChanged(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	contracts := make(map[string]ArtifactContract)
+	for _, contract := range registry.contracts() {
+		contracts[contract.Name] = contract
+	}
+	if len(contracts) != 3 {
+		t.Fatalf("Expected Token, Vault and Migrations, got %v", contracts)
+	}
+	if deployments := contracts["Vault"].Deployments; len(deployments) != 1 || deployments[0].ChainID != 31337 {
+		t.Errorf("Expected broadcast deployment of Vault, got %v", deployments)
+	}
+	if deployments := contracts["Migrations"].Deployments; len(deployments) != 1 || deployments[0].ChainID != 5777 {
+		t.Errorf("Expected Truffle deployment of Migrations, got %v", deployments)
+	}
+
+	// Deployments are resolved by the chain ID of the node
+	ctx := SetNodeAddress(context.Background(), "http://artifacts.test")
+	registry.chainIDs["http://artifacts.test"] = 31337
+	vaultABI := registry.ContractABI(ctx, common.HexToAddress("0xe7f1725e7734ce288f8367e1bb143e90bb3f0512"))
+	if vaultABI == nil {
+		t.Fatalf("Expected Vault ABI")
+	}
+	if _, ok := vaultABI.Methods["store"]; !ok {
+		t.Errorf("Expected Vault ABI with store method, got %v", vaultABI.Methods)
+	}
+	tokenABI := registry.ContractABI(ctx, common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3"))
+	if tokenABI == nil {
+		t.Fatalf("Expected Token ABI")
+	}
+	if _, ok := tokenABI.Methods["transfer"]; !ok {
+		t.Errorf("Expected Token ABI with transfer method, got %v", tokenABI.Methods)
+	}
+
+	// Storage layouts come from the Foundry artifact or the Hardhat build info
+	if layout := registry.StorageLayout(ctx, common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")); layout == nil || len(layout.Storage) != 1 || layout.Storage[0].Label != "_totalSupply" {
+		t.Errorf("Expected Token storage layout from the build info, got %v", layout)
+	}
+	if layout := registry.StorageLayout(ctx, common.HexToAddress("0xe7f1725e7734ce288f8367e1bb143e90bb3f0512")); layout == nil || len(layout.Storage) != 0 {
+		t.Errorf("Expected empty Vault storage layout from the artifact, got %v", layout)
+	}
+
+	// Source maps only come from the build info
+	if artifact := registry.SourceArtifact(ctx, common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")); artifact == nil || artifact.DeployedSourceMap != "0:17:0" || len(artifact.Sources) != 1 || artifact.Sources[0].Content != "contract Token {}" {
+		t.Errorf("Expected Token source artifact from the build info, got %v", artifact)
+	}
+	if artifact := registry.SourceArtifact(ctx, common.HexToAddress("0xe7f1725e7734ce288f8367e1bb143e90bb3f0512")); artifact != nil {
+		t.Errorf("Expected no Vault source artifact without build info, got %v", artifact)
+	}
+
+	// A chain reset of the node drops its chain ID and matches, other nodes keep theirs
+	registry.matches["http://artifacts.test/0x0000000000000000000000000000000000001001"] = nil
+	registry.matches["http://other.test/0x0000000000000000000000000000000000001001"] = nil
+	registry.forgetNode("http://artifacts.test")
+	if _, ok := registry.chainIDs["http://artifacts.test"]; ok || len(registry.matches) != 1 {
+		t.Errorf("Expected only the matches of the other node, got %v and chain IDs %v", registry.matches, registry.chainIDs)
+	}
+
+	// Unchanged files aren't reloaded
+	generation := registry.generation
+	if err := registry.reloadIfChanged(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if registry.generation != generation {
+		t.Errorf("Expected no reload without changes")
+	}
+}
+
+func TestArtifactBytecodeMatch(t *testing.T) {
+	// PUSH32 immutable placeholder, PUSH20 library placeholder, JUMPDEST and a 4 byte metadata section
+	deployedBytecode := "0x7f" + strings.Repeat("00", 32) + "73__$" + strings.Repeat("a", 34) + "$__5ba1010203" + "0004"
+	loaded, _, err := parseArtifactFile("Lib.json", artifactFile{
+		ABI:              []byte("[]"),
+		DeployedBytecode: []byte(`"` + deployedBytecode + `"`),
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	onChain := "0x7f" + strings.Repeat("11", 32) + "73" + strings.Repeat("22", 20) + "5ba1090909" + "0004"
+	if !loaded.matches(stripMetadata(common.FromHex(onChain))) {
+		t.Errorf("Expected bytecode with different immutables, libraries and metadata to match")
+	}
+
+	different := "0x7f" + strings.Repeat("11", 32) + "73" + strings.Repeat("22", 20) + "5ca1090909" + "0004"
+	if loaded.matches(stripMetadata(common.FromHex(different))) {
+		t.Errorf("Expected different code not to match")
+	}
+}
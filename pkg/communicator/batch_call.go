@@ -0,0 +1,429 @@
+package communicator
+
+import (
+	"context"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	BatchModeAuto      = "auto" // Multicall3 if it's available at the block, JSON-RPC batch otherwise
+	BatchModeRPC       = "rpc"
+	BatchModeMulticall = "multicall"
+
+	maxBatchCalls = 1000
+
+	// Calls per aggregate3 eth_call and per JSON-RPC batch, nodes limit both
+	batchChunkSize = 100
+)
+
+// Deployed bytecode of Multicall3, set on dev chains it isn't deployed to
+//
+//go:embed multicall3/runtime.hex
+var multicall3RuntimeHex string
+
+var (
+	multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+	multicall3Code    = common.FromHex(strings.TrimSpace(multicall3RuntimeHex))
+	multicall3ABI     = mustParseABI(`[{"type":"function","name":"aggregate3","stateMutability":"payable","inputs":[{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}]}],"outputs":[{"name":"returnData","type":"tuple[]","components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}]}]}]`)
+)
+
+type BatchCallRequest struct {
+	Mode  string `json:"mode"`  // auto, rpc or multicall, auto if empty
+	Block string `json:"block"` // default block of the calls, latest if empty
+
+	Calls []BatchCallItem `json:"calls"`
+
+	// Every view and pure function without inputs of these contracts is called after Calls
+	ReadAll []BatchReadAll `json:"read_all"`
+}
+
+type BatchCallItem struct {
+	ContractAddress string    `json:"contract_address"`
+	ContractABI     string    `json:"contract_abi"` // Registered ABI of the contract is used if empty
+	Method          string    `json:"method"`
+	Input           Arguments `json:"input"`
+	Block           string    `json:"block"` // overrides the block of the request
+}
+
+type BatchReadAll struct {
+	ContractAddress string `json:"contract_address"`
+	ContractABI     string `json:"contract_abi"` // Registered ABI of the contract is used if empty
+	Block           string `json:"block"`
+}
+
+type BatchCallResponse struct {
+	Results []BatchCallResult `json:"results"`
+}
+
+type BatchCallResult struct {
+	ContractAddress string                 `json:"contract_address"`
+	Method          string                 `json:"method"`
+	Block           string                 `json:"block"`
+	Mode            string                 `json:"mode,omitempty"` // rpc or multicall, empty if the call wasn't sent
+	RawResponse     string                 `json:"raw_response,omitempty"`
+	Decoded         map[string]interface{} `json:"decoded,omitempty"`
+	Revert          *RevertReason          `json:"revert,omitempty"`
+	Error           string                 `json:"error,omitempty"`
+}
+
+// batchCall is a prepared call, results are written back by index.
+type batchCall struct {
+	index    int
+	address  common.Address
+	abi      abi.ABI
+	method   abi.Method
+	callData []byte
+	block    interface{}
+}
+
+type aggregate3Call struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+type aggregate3Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+func BatchCall(ctx context.Context, req BatchCallRequest) (BatchCallResponse, error) {
+	return batchCallContracts(ctx, req)
+}
+
+func batchCallContracts(ctx context.Context, req BatchCallRequest) (BatchCallResponse, error) {
+	mode := strings.ToLower(req.Mode)
+	switch mode {
+	case "":
+		mode = BatchModeAuto
+	case BatchModeAuto, BatchModeRPC, BatchModeMulticall:
+	default:
+		return BatchCallResponse{}, fmt.Errorf("unsupported batch mode %q", req.Mode)
+	}
+
+	items, err := expandReadAll(ctx, req)
+	if err != nil {
+		return BatchCallResponse{}, err
+	}
+	if len(items) > maxBatchCalls {
+		return BatchCallResponse{}, fmt.Errorf("too many calls, the limit is %d", maxBatchCalls)
+	}
+
+	client, err := ethclient.DialContext(ctx, GetNodeAddress(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to connect to Ethereum client", slog.Any("err", err))
+		return BatchCallResponse{}, err
+	}
+	defer client.Close()
+
+	response := BatchCallResponse{
+		Results: make([]BatchCallResult, len(items)),
+	}
+
+	// Invalid items fail on their own, the rest is grouped by block as a multicall runs at a single block
+	groups := make(map[string][]batchCall)
+	var groupKeys []string
+	for i, item := range items {
+		call, err := prepareBatchCall(ctx, i, item, req.Block)
+		response.Results[i] = BatchCallResult{
+			ContractAddress: item.ContractAddress,
+			Method:          item.Method,
+			Block:           item.Block,
+		}
+		if response.Results[i].Block == "" {
+			response.Results[i].Block = req.Block
+		}
+		if err != nil {
+			response.Results[i].Error = err.Error()
+			continue
+		}
+		response.Results[i].ContractAddress = call.address.Hex()
+
+		key, err := json.Marshal(call.block)
+		if err != nil {
+			return BatchCallResponse{}, err
+		}
+		if _, ok := groups[string(key)]; !ok {
+			groupKeys = append(groupKeys, string(key))
+		}
+		groups[string(key)] = append(groups[string(key)], call)
+	}
+
+	for _, key := range groupKeys {
+		calls := groups[key]
+		multicall3, err := resolveMulticall3(ctx, client, mode, calls[0].block)
+		if err != nil {
+			setBatchError(calls, response.Results, err)
+			continue
+		}
+
+		for start := 0; start < len(calls); start += batchChunkSize {
+			chunk := calls[start:min(start+batchChunkSize, len(calls))]
+			if multicall3 != nil {
+				err = multicall(ctx, client, *multicall3, chunk, response.Results)
+				// The aggregate call can fail as a whole, e.g. when the calls exceed the gas cap of the node
+				if err != nil && mode == BatchModeAuto {
+					slog.WarnContext(ctx, "Multicall failed, sending the calls as a batch", slog.Any("err", err))
+					err = rpcBatchCall(ctx, client, chunk, response.Results)
+				}
+			} else {
+				err = rpcBatchCall(ctx, client, chunk, response.Results)
+			}
+			if err != nil {
+				setBatchError(chunk, response.Results, err)
+			}
+		}
+	}
+
+	return response, nil
+}
+
+// expandReadAll appends the view functions without inputs of the read all contracts to the calls.
+func expandReadAll(ctx context.Context, req BatchCallRequest) ([]BatchCallItem, error) {
+	items := append([]BatchCallItem{}, req.Calls...)
+	for _, readAll := range req.ReadAll {
+		if !common.IsHexAddress(readAll.ContractAddress) {
+			return nil, fmt.Errorf("invalid contract address %q", readAll.ContractAddress)
+		}
+		contractABI, err := resolveContractABI(ctx, readAll.ContractABI, common.HexToAddress(readAll.ContractAddress))
+		if err != nil {
+			return nil, err
+		}
+
+		var names []string
+		for name, method := range contractABI.Methods {
+			if method.IsConstant() && len(method.Inputs) == 0 {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			items = append(items, BatchCallItem{
+				ContractAddress: readAll.ContractAddress,
+				ContractABI:     readAll.ContractABI,
+				Method:          name,
+				Block:           readAll.Block,
+			})
+		}
+	}
+	return items, nil
+}
+
+func prepareBatchCall(ctx context.Context, index int, item BatchCallItem, defaultBlock string) (batchCall, error) {
+	if !common.IsHexAddress(item.ContractAddress) {
+		return batchCall{}, fmt.Errorf("invalid contract address %q", item.ContractAddress)
+	}
+	address := common.HexToAddress(item.ContractAddress)
+	contractABI, err := resolveContractABI(ctx, item.ContractABI, address)
+	if err != nil {
+		return batchCall{}, err
+	}
+	callData, method, err := getCallData(ctx, contractABI, item.Method, item.Input)
+	if err != nil {
+		return batchCall{}, fmt.Errorf("failed to get call data: %v", err)
+	}
+
+	blockStr := item.Block
+	if blockStr == "" {
+		blockStr = defaultBlock
+	}
+	block, err := parseBlockParameter(blockStr)
+	if err != nil {
+		return batchCall{}, err
+	}
+
+	return batchCall{
+		index:    index,
+		address:  address,
+		abi:      contractABI,
+		method:   method,
+		callData: callData,
+		block:    block,
+	}, nil
+}
+
+// resolveMulticall3 returns the Multicall3 contract to use at the block, nil if the calls are sent as a
+// JSON-RPC batch. Multicall3 is set on dev chains it isn't deployed to if the calls read the current state.
+func resolveMulticall3(ctx context.Context, client *ethclient.Client, mode string, block interface{}) (*common.Address, error) {
+	if mode == BatchModeRPC {
+		return nil, nil
+	}
+
+	code, err := codeAtBlock(ctx, client, multicall3Address, block)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) > 0 {
+		return &multicall3Address, nil
+	}
+
+	if block == "latest" || block == "pending" {
+		installed, err := installMulticall3(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if installed {
+			return &multicall3Address, nil
+		}
+	}
+
+	if mode == BatchModeMulticall {
+		return nil, fmt.Errorf("Multicall3 is not deployed at block %v", block)
+	}
+	return nil, nil
+}
+
+// installMulticall3 sets the Multicall3 code on dev nodes, false is returned for other nodes.
+func installMulticall3(ctx context.Context) (bool, error) {
+	node, err := dialDevNode(ctx)
+	if err != nil {
+		slog.DebugContext(ctx, "Not a dev node, Multicall3 isn't deployed", slog.Any("err", err))
+		return false, nil
+	}
+	defer node.Close()
+
+	if err := node.setState(ctx, "setCode", multicall3Address, hexutil.Bytes(multicall3Code)); err != nil {
+		return false, err
+	}
+	slog.InfoContext(ctx, "Deployed Multicall3", slog.Any("address", multicall3Address.Hex()), slog.Any("dialect", node.dialect))
+	return true, nil
+}
+
+func codeAtBlock(ctx context.Context, client *ethclient.Client, address common.Address, block interface{}) ([]byte, error) {
+	var code hexutil.Bytes
+	if err := client.Client().CallContext(ctx, &code, "eth_getCode", address, block); err != nil {
+		slog.ErrorContext(ctx, "Failed to get code", slog.Any("address", address.Hex()), slog.Any("err", err))
+		return nil, fmt.Errorf("failed to get code: %v", err)
+	}
+	return code, nil
+}
+
+func multicall(ctx context.Context, client *ethclient.Client, aggregator common.Address, calls []batchCall, results []BatchCallResult) error {
+	aggregateCalls := make([]aggregate3Call, 0, len(calls))
+	for _, call := range calls {
+		aggregateCalls = append(aggregateCalls, aggregate3Call{
+			Target:       call.address,
+			AllowFailure: true,
+			CallData:     call.callData,
+		})
+	}
+	callData, err := multicall3ABI.Pack("aggregate3", aggregateCalls)
+	if err != nil {
+		return fmt.Errorf("failed to pack aggregate3: %v", err)
+	}
+
+	data, err := callContract(ctx, client, ethereum.CallMsg{To: &aggregator, Data: callData}, calls[0].block, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to call aggregate3", slog.Any("address", aggregator.Hex()), slog.Any("err", err))
+		return fmt.Errorf("failed to call aggregate3: %v", err)
+	}
+	aggregateResults, err := unpackAggregate3(data)
+	if err != nil {
+		return err
+	}
+	if len(aggregateResults) != len(calls) {
+		return fmt.Errorf("aggregate3 returned %d results for %d calls", len(aggregateResults), len(calls))
+	}
+
+	for i, call := range calls {
+		results[call.index].Mode = BatchModeMulticall
+		if !aggregateResults[i].Success {
+			setBatchRevert(&results[call.index], call, aggregateResults[i].ReturnData)
+			continue
+		}
+		setBatchResult(ctx, &results[call.index], call, aggregateResults[i].ReturnData)
+	}
+	return nil
+}
+
+func unpackAggregate3(data []byte) ([]aggregate3Result, error) {
+	values, err := multicall3ABI.Unpack("aggregate3", data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack aggregate3: %v", err)
+	}
+	var results []aggregate3Result
+	if len(values) > 0 {
+		results = *abi.ConvertType(values[0], new([]aggregate3Result)).(*[]aggregate3Result)
+	}
+	return results, nil
+}
+
+func rpcBatchCall(ctx context.Context, client *ethclient.Client, calls []batchCall, results []BatchCallResult) error {
+	elems := make([]rpc.BatchElem, 0, len(calls))
+	for _, call := range calls {
+		elems = append(elems, rpc.BatchElem{
+			Method: "eth_call",
+			Args: []interface{}{map[string]interface{}{
+				"to":    call.address,
+				"input": hexutil.Bytes(call.callData),
+			}, call.block},
+			Result: new(hexutil.Bytes),
+		})
+	}
+	if err := client.Client().BatchCallContext(ctx, elems); err != nil {
+		slog.ErrorContext(ctx, "Failed to send batch", slog.Any("err", err))
+		return fmt.Errorf("failed to send batch: %v", err)
+	}
+
+	for i, call := range calls {
+		result := &results[call.index]
+		result.Mode = BatchModeRPC
+		if err := elems[i].Error; err != nil {
+			if revertData, ok := revertDataFromError(err); ok {
+				setBatchRevert(result, call, revertData)
+				continue
+			}
+			result.Error = err.Error()
+			continue
+		}
+		setBatchResult(ctx, result, call, *elems[i].Result.(*hexutil.Bytes))
+	}
+	return nil
+}
+
+func setBatchError(calls []batchCall, results []BatchCallResult, err error) {
+	for _, call := range calls {
+		results[call.index].Error = err.Error()
+	}
+}
+
+func setBatchRevert(result *BatchCallResult, call batchCall, data []byte) {
+	revert := decodeRevert(&call.abi, data)
+	result.RawResponse = hex.EncodeToString(data)
+	result.Revert = &revert
+}
+
+func setBatchResult(ctx context.Context, result *BatchCallResult, call batchCall, data []byte) {
+	result.RawResponse = hex.EncodeToString(data)
+	if len(data) == 0 {
+		result.Error = "no result returned from contract call"
+		return
+	}
+	decoded, err := parseResult(ctx, call.method, data)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to parse result: %v", err)
+		return
+	}
+	result.Decoded = decoded
+}
+
+func mustParseABI(abiJSON string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
@@ -0,0 +1,215 @@
+package communicator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestMulticall3(t *testing.T) {
+	// Code hash of the canonical deployment
+	if hash := crypto.Keccak256Hash(multicall3Code); hash != common.HexToHash("0xd5c15df687b16f2ff992fc8d767b4216323184a2bbc6ee2f9c398c318e770891") {
+		t.Fatalf("Unexpected Multicall3 code hash %s", hash.Hex())
+	}
+
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	if err != nil {
+		t.Fatal(err)
+	}
+	answer := common.HexToAddress("0x1001")   // returns 42
+	reverter := common.HexToAddress("0x1002") // reverts without data
+	echo := common.HexToAddress("0x1003")     // returns its calldata
+	statedb.SetCode(answer, common.FromHex("602a60005260206000f3"))
+	statedb.SetCode(reverter, common.FromHex("60006000fd"))
+	statedb.SetCode(echo, common.FromHex("366000600037366000f3"))
+	statedb.SetCode(multicall3Address, multicall3Code)
+	config := &runtime.Config{State: statedb}
+
+	echoData := bytes.Repeat([]byte{0xab}, 37)
+	input, err := multicall3ABI.Pack("aggregate3", []aggregate3Call{
+		{Target: answer, AllowFailure: true},
+		{Target: reverter, AllowFailure: true},
+		{Target: echo, AllowFailure: true, CallData: echoData},
+		{Target: echo, AllowFailure: false},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, _, err := runtime.Call(multicall3Address, input, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := unpackAggregate3(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []aggregate3Result{
+		{Success: true, ReturnData: common.LeftPadBytes([]byte{42}, 32)},
+		{Success: false, ReturnData: []byte{}},
+		{Success: true, ReturnData: echoData},
+		{Success: true, ReturnData: []byte{}},
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %d", len(expected), len(results))
+	}
+	for i := range expected {
+		if results[i].Success != expected[i].Success || !bytes.Equal(results[i].ReturnData, expected[i].ReturnData) {
+			t.Errorf("Expected %v for call %d, got %v", expected[i], i, results[i])
+		}
+	}
+
+	// A failing call without allowFailure reverts the whole batch
+	input, err = multicall3ABI.Pack("aggregate3", []aggregate3Call{
+		{Target: answer, AllowFailure: true},
+		{Target: reverter, AllowFailure: false},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := runtime.Call(multicall3Address, input, config); err == nil {
+		t.Error("Expected the batch to revert")
+	}
+
+	// Empty batches return an empty array
+	input, err = multicall3ABI.Pack("aggregate3", []aggregate3Call{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, _, err = runtime.Call(multicall3Address, input, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results, err := unpackAggregate3(output); err != nil || len(results) != 0 {
+		t.Errorf("Expected no results, got %v (%v)", results, err)
+	}
+}
+
+func TestExpandReadAll(t *testing.T) {
+	contractABI := `[
+		{"type":"function","name":"totalSupply","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+		{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+		{"type":"function","name":"name","stateMutability":"pure","inputs":[],"outputs":[{"name":"","type":"string"}]},
+		{"type":"function","name":"mint","stateMutability":"nonpayable","inputs":[],"outputs":[]}
+	]`
+	items, err := expandReadAll(context.Background(), BatchCallRequest{
+		Calls: []BatchCallItem{{ContractAddress: "0x01", Method: "balanceOf"}},
+		ReadAll: []BatchReadAll{{
+			ContractAddress: "0x0000000000000000000000000000000000000002",
+			ContractABI:     contractABI,
+			Block:           "0x10",
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var methods []string
+	for _, item := range items {
+		methods = append(methods, item.Method)
+	}
+	if got := strings.Join(methods, ","); got != "balanceOf,name,totalSupply" {
+		t.Errorf("Expected balanceOf,name,totalSupply, got %s", got)
+	}
+	if items[1].Block != "0x10" || items[1].ContractABI != contractABI {
+		t.Errorf("Expected the read all block and ABI to be kept, got %v", items[1])
+	}
+}
+
+func TestSetBatchResult(t *testing.T) {
+	contractABI, err := abi.JSON(strings.NewReader(`[
+		{"type":"function","name":"totalSupply","stateMutability":"view","inputs":[],"outputs":[{"name":"supply","type":"uint256"}]},
+		{"type":"error","name":"Paused","inputs":[]}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := batchCall{abi: contractABI, method: contractABI.Methods["totalSupply"]}
+
+	var result BatchCallResult
+	setBatchResult(context.Background(), &result, call, common.LeftPadBytes([]byte{42}, 32))
+	if result.Error != "" || fmt.Sprint(result.Decoded["supply"]) != "42" {
+		t.Errorf("Expected supply 42, got %v (%s)", result.Decoded, result.Error)
+	}
+
+	result = BatchCallResult{}
+	setBatchResult(context.Background(), &result, call, nil)
+	if result.Error == "" {
+		t.Error("Expected an error for an empty result")
+	}
+
+	result = BatchCallResult{}
+	paused := contractABI.Errors["Paused"].ID
+	setBatchRevert(&result, call, paused[:4])
+	if result.Revert == nil || result.Revert.ErrorName != "Paused" {
+		t.Errorf("Expected the Paused error, got %v", result.Revert)
+	}
+}
+
+// batchTestNode has Multicall3 deployed, but aggregate calls exceed its gas cap
+type batchTestNode struct{}
+
+func (batchTestNode) GetCode(address common.Address, block json.RawMessage) hexutil.Bytes {
+	if address == multicall3Address {
+		return multicall3Code
+	}
+	return nil
+}
+
+func (batchTestNode) Call(args map[string]interface{}, block json.RawMessage) (hexutil.Bytes, error) {
+	if to, _ := args["to"].(string); common.HexToAddress(to) == multicall3Address {
+		return nil, errors.New("gas required exceeds allowance")
+	}
+	return common.LeftPadBytes([]byte{42}, 32), nil
+}
+
+func TestBatchCallFailedMulticall(t *testing.T) {
+	ctx := newTestNode(t, map[string]interface{}{"eth": batchTestNode{}})
+	req := BatchCallRequest{
+		Calls: []BatchCallItem{{
+			ContractAddress: "0x5FbDB2315678afecb367f032d93F642f64180aa3",
+			ContractABI:     `[{"type":"function","name":"totalSupply","stateMutability":"view","inputs":[],"outputs":[{"name":"supply","type":"uint256"}]}]`,
+			Method:          "totalSupply",
+		}, {
+			ContractAddress: "invalid",
+			Method:          "totalSupply",
+		}},
+	}
+
+	// Auto mode sends the calls of a failed multicall as a batch
+	resp, err := batchCallContracts(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := resp.Results[0]; result.Mode != BatchModeRPC || fmt.Sprint(result.Decoded["supply"]) != "42" {
+		t.Errorf("Expected supply 42 from the batch, got %+v", result)
+	}
+	if resp.Results[1].Error == "" {
+		t.Errorf("Expected an error for the invalid address, got %+v", resp.Results[1])
+	}
+
+	// Multicall mode reports the failure for each call
+	req.Mode = BatchModeMulticall
+	resp, err = batchCallContracts(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := resp.Results[0]; !strings.Contains(result.Error, "gas required exceeds allowance") || result.Decoded != nil {
+		t.Errorf("Expected the multicall error, got %+v", result)
+	}
+	if !strings.Contains(resp.Results[1].Error, "invalid contract address") {
+		t.Errorf("Expected the invalid address error to be kept, got %+v", resp.Results[1])
+	}
+}
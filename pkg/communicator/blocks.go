@@ -2,6 +2,7 @@ package communicator
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"math/big"
 
@@ -27,6 +28,7 @@ type Block struct {
 }
 
 type Header struct {
+	Hash             string           `json:"hash"`
 	ParentHash       string           `json:"parent_hash"`
 	UncleHash        string           `json:"uncle_hash"`
 	Coinbase         string           `json:"miner"`
@@ -61,6 +63,21 @@ func getLatestNBlock(ctx context.Context, req GetLatestNBlockRequest) (GetLatest
 		return GetLatestNBlockResponse{}, err
 	}
 
+	// Blocks already indexed are served from the store, the rest from the node. The store is skipped
+	// until the indexer catches up with a chain reset.
+	indexer := getIndexer(ctx)
+	if indexer != nil && !indexer.isConsistent() {
+		indexer = nil
+	}
+
+	if req.BlockNumber == 0 && indexer != nil {
+		head, ok, err := indexer.store.Head()
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to get stored head", slog.Any("err", err))
+		} else if ok {
+			req.BlockNumber = int64(head)
+		}
+	}
 	if req.BlockNumber == 0 {
 		blockNumber, err := client.BlockNumber(ctx)
 		if err != nil {
@@ -77,30 +94,72 @@ func getLatestNBlock(ctx context.Context, req GetLatestNBlockRequest) (GetLatest
 		if nextIndex < 1 {
 			return response, nil // No more blocks to retrieve
 		}
+		if indexer != nil {
+			if block, ok := indexer.block(ctx, uint64(nextIndex)); ok {
+				response.Blocks = append(response.Blocks, block)
+				continue
+			}
+		}
+
 		block, err := client.BlockByNumber(ctx, big.NewInt(nextIndex))
 		if err != nil {
 			slog.ErrorContext(ctx, "Failed to retrieve block", slog.Any("block_number", nextIndex), slog.Any("err", err))
 			return GetLatestNBlockResponse{}, err
 		}
 
-		var transactions []Transaction
-		for j, transaction := range block.Transactions() {
-			parsedTransaction, err := parseTransaction(transaction, block.Number().String(), int64(j))
-			if err != nil {
-				slog.ErrorContext(ctx, "Failed to parse transaction", slog.Any("block_number", nextIndex), slog.Any("transaction_index", j), slog.Any("err", err))
-				return GetLatestNBlockResponse{}, err
-			}
-			transactions = append(transactions, parsedTransaction)
+		parsedBlock, err := parseBlock(ctx, block)
+		if err != nil {
+			return GetLatestNBlockResponse{}, err
 		}
-		response.Blocks = append(response.Blocks, Block{
-			Header:       parseHeader(block.Header()),
-			Transactions: transactions,
-		})
+		if err := addReceipts(ctx, client, block, &parsedBlock); err != nil {
+			slog.ErrorContext(ctx, "Failed to get receipts", slog.Any("block_number", nextIndex), slog.Any("err", err))
+			return GetLatestNBlockResponse{}, err
+		}
+		response.Blocks = append(response.Blocks, parsedBlock)
 	}
 
 	return response, nil
 }
 
+func parseBlock(ctx context.Context, block *types.Block) (Block, error) {
+	var transactions []Transaction
+	for j, transaction := range block.Transactions() {
+		parsedTransaction, err := parseTransaction(transaction, block.Number().String(), int64(j))
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to parse transaction", slog.Any("block_number", block.Number()), slog.Any("transaction_index", j), slog.Any("err", err))
+			return Block{}, err
+		}
+		transactions = append(transactions, parsedTransaction)
+	}
+
+	return Block{
+		Header:       parseHeader(block.Header()),
+		Transactions: transactions,
+	}, nil
+}
+
+// addReceipts sets the receipt of every transaction and the revert reason of the failed ones, the
+// same way for blocks from the node and blocks from the store.
+func addReceipts(ctx context.Context, client *ethclient.Client, block *types.Block, parsedBlock *Block) error {
+	for j := range parsedBlock.Transactions {
+		receipt, err := client.TransactionReceipt(ctx, block.Transactions()[j].Hash())
+		if err != nil {
+			return fmt.Errorf("failed to get transaction receipt of %s: %v", parsedBlock.Transactions[j].Hash, err)
+		}
+		parsedReceipt := parseReceipt(receipt)
+		parsedBlock.Transactions[j].Receipt = &parsedReceipt
+
+		if receipt.Status == types.ReceiptStatusFailed {
+			revert, err := replayRevert(ctx, client, block.Transactions()[j], receipt.BlockNumber, nil)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to get revert reason", slog.Any("hash", parsedBlock.Transactions[j].Hash), slog.Any("err", err))
+			}
+			parsedBlock.Transactions[j].Revert = revert
+		}
+	}
+	return nil
+}
+
 func parseHeader(header *types.Header) Header {
 	blobGasUsed := uint64(0)
 	if header.BlobGasUsed != nil {
@@ -112,6 +171,7 @@ func parseHeader(header *types.Header) Header {
 		excessBlobGas = *header.ExcessBlobGas
 	}
 	return Header{
+		Hash:             header.Hash().Hex(),
 		ParentHash:       header.ParentHash.Hex(),
 		UncleHash:        header.UncleHash.Hex(),
 		Coinbase:         header.Coinbase.Hex(),
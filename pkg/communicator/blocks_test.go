@@ -1,25 +1,65 @@
 package communicator
 
 import (
-	"context"
-	"fmt"
 	"testing"
-	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
 )
 
 func TestGetLatestNBlock(t *testing.T) {
-	// Define the context
-	ctx := context.Background()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient := common.HexToAddress("0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC")
+	chain := newTestChain(t,
+		[]*types.Transaction{newTestTransaction(t, key, 0, recipient)},
+		[]*types.Transaction{},
+		[]*types.Transaction{newTestTransaction(t, key, 1, recipient), newTestTransaction(t, key, 2, recipient)},
+		[]*types.Transaction{},
+	)
+	blocks := chain.blocks
+	chain.setBlocks(blocks[:4])
+	ctx := newTestNode(t, map[string]interface{}{"eth": chain})
 
-	mes := time.Now()
-	// Call the function with a valid client and context
+	// Blocks from the node come with receipts, the walk stops before the genesis block
 	resp, err := getLatestNBlock(ctx, GetLatestNBlockRequest{
 		NumberOfBlocks: 7,
 	})
 	if err != nil {
 		t.Fatalf("Expected no error, got %v", err)
 	}
-	fmt.Println(resp)
+	if len(resp.Blocks) != 3 || resp.Blocks[0].Header.Number != "3" || resp.Blocks[2].Header.Number != "1" {
+		t.Fatalf("Expected blocks 3 to 1, got %+v", resp.Blocks)
+	}
+	if len(resp.Blocks[0].Transactions) != 2 || resp.Blocks[0].Transactions[1].Receipt == nil || resp.Blocks[0].Transactions[1].Receipt.Status != "success" {
+		t.Errorf("Expected block 3 with two receipts, got %+v", resp.Blocks[0].Transactions)
+	}
+
+	// With the indexer the latest block is the stored head, even if the node is ahead
+	store := NewStore(memorydb.New())
+	defer store.Close()
+	indexer := NewIndexer(GetNodeAddress(ctx), store, 0)
+	if err := indexer.sync(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	SetIndexer(indexer)
+	defer SetIndexer(nil)
+	chain.setBlocks(blocks)
 
-	fmt.Println(time.Since(mes))
+	resp, err = getLatestNBlock(ctx, GetLatestNBlockRequest{
+		NumberOfBlocks: 2,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(resp.Blocks) != 2 || resp.Blocks[0].Header.Number != "3" {
+		t.Fatalf("Expected to start at the stored head 3, got %+v", resp.Blocks)
+	}
+	if resp.Blocks[0].Transactions[0].Receipt == nil || resp.Blocks[0].Transactions[0].Receipt.GasUsed != 21000 {
+		t.Errorf("Expected stored blocks with receipts, got %+v", resp.Blocks[0].Transactions[0])
+	}
 }
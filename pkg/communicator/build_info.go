@@ -0,0 +1,154 @@
+package communicator
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// buildInfoFile holds the fields of the solc input and output saved by Hardhat (artifacts/build-info)
+// and Foundry (out/build-info, with build_info enabled)
+type buildInfoFile struct {
+	Input struct {
+		Sources map[string]struct {
+			Content string `json:"content"`
+		} `json:"sources"`
+	} `json:"input"`
+	Output struct {
+		// Source name -> contract name -> compiler output
+		Contracts map[string]map[string]struct {
+			StorageLayout *StorageLayout `json:"storageLayout"`
+			EVM           struct {
+				DeployedBytecode struct {
+					Object    string `json:"object"`
+					SourceMap string `json:"sourceMap"`
+				} `json:"deployedBytecode"`
+			} `json:"evm"`
+		} `json:"contracts"`
+		Sources map[string]struct {
+			ID int `json:"id"`
+		} `json:"sources"`
+	} `json:"output"`
+}
+
+// buildInfoContract is the compiler output of a contract which isn't part of its artifact file.
+type buildInfoContract struct {
+	storageLayout  *StorageLayout
+	sourceArtifact *SourceArtifact // nil without a source map
+}
+
+type buildInfoIndex struct {
+	contracts map[string]*buildInfoContract   // source name:contract name -> output
+	byName    map[string][]*buildInfoContract // contract name -> outputs, for artifacts without a source name
+}
+
+// buildInfo returns the compiler output of the artifact from the build info files, which are only
+// read on first use as they are large.
+func (r *ArtifactRegistry) buildInfo(ctx context.Context, loaded *artifact) *buildInfoContract {
+	r.mu.RLock()
+	index := r.buildInfos
+	upToDate := r.buildInfosGeneration == r.generation
+	generation := r.generation
+	r.mu.RUnlock()
+
+	if index == nil || !upToDate {
+		var err error
+		index, err = loadBuildInfos(ctx, r.path)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to load build info", slog.Any("path", r.path), slog.Any("err", err))
+			return nil
+		}
+		r.mu.Lock()
+		if r.generation == generation {
+			r.buildInfos = index
+			r.buildInfosGeneration = generation
+		}
+		r.mu.Unlock()
+	}
+
+	if contract, ok := index.contracts[loaded.sourceName+":"+loaded.name]; ok {
+		return contract
+	}
+	// Foundry artifacts don't have the source name, the contract name has to be unique then
+	if contracts := index.byName[loaded.name]; loaded.sourceName == "" && len(contracts) == 1 {
+		return contracts[0]
+	}
+	return nil
+}
+
+func loadBuildInfos(ctx context.Context, path string) (*buildInfoIndex, error) {
+	index := &buildInfoIndex{
+		contracts: make(map[string]*buildInfoContract),
+		byName:    make(map[string][]*buildInfoContract),
+	}
+
+	err := filepath.WalkDir(path, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == "node_modules" || entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Base(filepath.Dir(path)) != "build-info" || filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var file buildInfoFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			slog.InfoContext(ctx, "Skipping invalid build info", slog.Any("path", path), slog.Any("err", err))
+			return nil
+		}
+
+		// The sources are shared by the contracts of the build
+		sources := make([]SourceFile, 0, len(file.Output.Sources))
+		for name, source := range file.Output.Sources {
+			sources = append(sources, SourceFile{
+				ID:      source.ID,
+				Name:    name,
+				Content: file.Input.Sources[name].Content,
+			})
+		}
+		sort.Slice(sources, func(i, j int) bool {
+			return sources[i].ID < sources[j].ID
+		})
+
+		for sourceName, contracts := range file.Output.Contracts {
+			for name, output := range contracts {
+				contract := &buildInfoContract{
+					storageLayout: output.StorageLayout,
+				}
+				if deployed := output.EVM.DeployedBytecode; deployed.SourceMap != "" {
+					contract.sourceArtifact = &SourceArtifact{
+						DeployedBytecode:  deployed.Object,
+						DeployedSourceMap: deployed.SourceMap,
+						Sources:           sources,
+					}
+				}
+				// A contract compiled in multiple builds is taken from the first one
+				key := sourceName + ":" + name
+				if _, ok := index.contracts[key]; ok {
+					continue
+				}
+				index.contracts[key] = contract
+				index.byName[name] = append(index.byName[name], contract)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return index, nil
+}
@@ -0,0 +1,324 @@
+package communicator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	registeredContractRegistryMu sync.RWMutex
+	registeredContractRegistry   *ContractRegistry
+
+	errContractRegistryDisabled = errors.New("contract registry is not configured")
+)
+
+// ContractRegistry holds the ABIs, names and tags saved for contract addresses, persisted to a JSON file.
+type ContractRegistry struct {
+	path string
+
+	mu        sync.RWMutex
+	contracts map[common.Address]registeredContract
+}
+
+type registeredContract struct {
+	Contract
+	abi abi.ABI
+}
+
+type Contract struct {
+	Address        string          `json:"address"`
+	Name           string          `json:"name"`
+	Tags           []string        `json:"tags"`
+	ContractABI    string          `json:"contract_abi"`
+	StorageLayout  *StorageLayout  `json:"storage_layout,omitempty"`
+	SourceArtifact *SourceArtifact `json:"source_artifact,omitempty"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+type SaveContractRequest struct {
+	Address     string   `json:"address"`
+	Name        string   `json:"name"`
+	Tags        []string `json:"tags"`
+	ContractABI string   `json:"contract_abi"`
+
+	// solc storageLayout output, used to name the storage slots of the contract
+	StorageLayout *StorageLayout `json:"storage_layout"`
+
+	// Compiler output used to map the executed code of the contract to its source
+	SourceArtifact *SourceArtifact `json:"source_artifact"`
+}
+
+type GetContractsRequest struct {
+	Tag string `json:"tag"` // Only contracts with the tag are returned if set
+}
+
+type GetContractsResponse struct {
+	Contracts []Contract `json:"contracts"`
+}
+
+type GetContractRequest struct {
+	Address string `json:"address"`
+}
+
+type DeleteContractRequest struct {
+	Address string `json:"address"`
+}
+
+// OpenContractRegistry loads the registry from the file, it's created on the first save if it doesn't exist.
+func OpenContractRegistry(path string) (*ContractRegistry, error) {
+	registry := &ContractRegistry{
+		path:      path,
+		contracts: make(map[common.Address]registeredContract),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return registry, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read contract registry: %v", err)
+	}
+
+	var contracts []Contract
+	if err := json.Unmarshal(data, &contracts); err != nil {
+		return nil, fmt.Errorf("failed to parse contract registry %s: %v", path, err)
+	}
+	for _, contract := range contracts {
+		parsedABI, err := abi.JSON(strings.NewReader(contract.ContractABI))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ABI of %s: %v", contract.Address, err)
+		}
+		registry.contracts[common.HexToAddress(contract.Address)] = registeredContract{
+			Contract: contract,
+			abi:      parsedABI,
+		}
+	}
+
+	return registry, nil
+}
+
+// SetContractRegistry registers the contract registry, its ABIs take precedence over the artifacts.
+func SetContractRegistry(registry *ContractRegistry) {
+	registeredContractRegistryMu.Lock()
+	defer registeredContractRegistryMu.Unlock()
+	registeredContractRegistry = registry
+
+	if registry != nil {
+		database := getSignatureDatabase()
+		registry.mu.RLock()
+		defer registry.mu.RUnlock()
+		for _, contract := range registry.contracts {
+			database.addABI(contract.abi)
+		}
+	}
+}
+
+func getContractRegistry() *ContractRegistry {
+	registeredContractRegistryMu.RLock()
+	defer registeredContractRegistryMu.RUnlock()
+	return registeredContractRegistry
+}
+
+func SaveContract(ctx context.Context, req SaveContractRequest) (Contract, error) {
+	return saveContract(ctx, req)
+}
+
+func saveContract(ctx context.Context, req SaveContractRequest) (Contract, error) {
+	registry := getContractRegistry()
+	if registry == nil {
+		return Contract{}, errContractRegistryDisabled
+	}
+	if !common.IsHexAddress(req.Address) {
+		return Contract{}, fmt.Errorf("invalid address %q", req.Address)
+	}
+	parsedABI, err := abi.JSON(strings.NewReader(req.ContractABI))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse contract ABI", slog.Any("err", err))
+		return Contract{}, fmt.Errorf("failed to parse ABI: %v", err)
+	}
+
+	address := common.HexToAddress(req.Address)
+	contract := Contract{
+		Address:        address.Hex(),
+		Name:           strings.TrimSpace(req.Name),
+		Tags:           normalizeTags(req.Tags),
+		ContractABI:    req.ContractABI,
+		StorageLayout:  req.StorageLayout,
+		SourceArtifact: req.SourceArtifact,
+		UpdatedAt:      time.Now().UTC(),
+	}
+
+	getSignatureDatabase().addABI(parsedABI)
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	previous, existed := registry.contracts[address]
+	registry.contracts[address] = registeredContract{Contract: contract, abi: parsedABI}
+	if err := registry.persist(); err != nil {
+		if existed {
+			registry.contracts[address] = previous
+		} else {
+			delete(registry.contracts, address)
+		}
+		slog.ErrorContext(ctx, "Failed to save contract registry", slog.Any("path", registry.path), slog.Any("err", err))
+		return Contract{}, err
+	}
+
+	return contract, nil
+}
+
+func GetContracts(ctx context.Context, req GetContractsRequest) (GetContractsResponse, error) {
+	return getContracts(ctx, req)
+}
+
+func getContracts(_ context.Context, req GetContractsRequest) (GetContractsResponse, error) {
+	registry := getContractRegistry()
+	if registry == nil {
+		return GetContractsResponse{}, errContractRegistryDisabled
+	}
+
+	response := GetContractsResponse{Contracts: []Contract{}}
+	for _, contract := range registry.list() {
+		if req.Tag != "" && !hasTag(contract.Tags, req.Tag) {
+			continue
+		}
+		response.Contracts = append(response.Contracts, contract)
+	}
+
+	return response, nil
+}
+
+func GetContract(ctx context.Context, req GetContractRequest) (Contract, error) {
+	return getContract(ctx, req)
+}
+
+func getContract(_ context.Context, req GetContractRequest) (Contract, error) {
+	registry := getContractRegistry()
+	if registry == nil {
+		return Contract{}, errContractRegistryDisabled
+	}
+
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	contract, ok := registry.contracts[common.HexToAddress(req.Address)]
+	if !ok {
+		return Contract{}, fmt.Errorf("contract %s is not registered", req.Address)
+	}
+	return contract.Contract, nil
+}
+
+func DeleteContract(ctx context.Context, req DeleteContractRequest) error {
+	return deleteContract(ctx, req)
+}
+
+func deleteContract(ctx context.Context, req DeleteContractRequest) error {
+	registry := getContractRegistry()
+	if registry == nil {
+		return errContractRegistryDisabled
+	}
+
+	address := common.HexToAddress(req.Address)
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	previous, ok := registry.contracts[address]
+	if !ok {
+		return fmt.Errorf("contract %s is not registered", req.Address)
+	}
+	delete(registry.contracts, address)
+	if err := registry.persist(); err != nil {
+		registry.contracts[address] = previous
+		slog.ErrorContext(ctx, "Failed to save contract registry", slog.Any("path", registry.path), slog.Any("err", err))
+		return err
+	}
+
+	return nil
+}
+
+// contract returns the registered contract of the address.
+func (r *ContractRegistry) contract(address common.Address) (registeredContract, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	contract, ok := r.contracts[address]
+	return contract, ok
+}
+
+func (r *ContractRegistry) list() []Contract {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	contracts := make([]Contract, 0, len(r.contracts))
+	for _, contract := range r.contracts {
+		contracts = append(contracts, contract.Contract)
+	}
+	sort.Slice(contracts, func(i, j int) bool {
+		return contracts[i].Address < contracts[j].Address
+	})
+	return contracts
+}
+
+// persist writes the registry to a temporary file first, so a failed write doesn't corrupt it.
+// The caller must hold the write lock.
+func (r *ContractRegistry) persist() error {
+	contracts := make([]Contract, 0, len(r.contracts))
+	for _, contract := range r.contracts {
+		contracts = append(contracts, contract.Contract)
+	}
+	sort.Slice(contracts, func(i, j int) bool {
+		return contracts[i].Address < contracts[j].Address
+	})
+
+	data, err := json.MarshalIndent(contracts, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal contract registry: %v", err)
+	}
+	if dir := filepath.Dir(r.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create contract registry directory: %v", err)
+		}
+	}
+	tmpPath := r.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write contract registry: %v", err)
+	}
+	if err := os.Rename(tmpPath, r.path); err != nil {
+		return fmt.Errorf("failed to write contract registry: %v", err)
+	}
+	return nil
+}
+
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]struct{})
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if _, ok := seen[tag]; ok || tag == "" {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+func hasTag(tags []string, tag string) bool {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	for _, existing := range tags {
+		if existing == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package communicator
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestContractRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "contracts.json")
+	registry, err := OpenContractRegistry(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	SetContractRegistry(registry)
+	t.Cleanup(func() {
+		SetContractRegistry(nil)
+	})
+
+	ctx := context.Background()
+	_, err = SaveContract(ctx, SaveContractRequest{
+		Address:     "0x5fbdb2315678afecb367f032d93f642f64180aa3",
+		Name:        "USDC",
+		Tags:        []string{"Token", " stablecoin ", "token"},
+		ContractABI: contractABI,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := SaveContract(ctx, SaveContractRequest{Address: "0x1", ContractABI: contractABI}); err == nil {
+		t.Errorf("Expected invalid address error")
+	}
+
+	// The registry survives a restart
+	registry, err = OpenContractRegistry(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	SetContractRegistry(registry)
+
+	contracts, err := GetContracts(ctx, GetContractsRequest{Tag: "TOKEN"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(contracts.Contracts) != 1 {
+		t.Fatalf("Expected 1 contract, got %v", contracts.Contracts)
+	}
+	contract := contracts.Contracts[0]
+	if contract.Address != "0x5FbDB2315678afecb367f032d93F642f64180aa3" || len(contract.Tags) != 2 || contract.Tags[0] != "stablecoin" {
+		t.Errorf("Unexpected contract %v", contract)
+	}
+
+	call := decodeTransactionCall(ctx, Transaction{
+		To:    "0x5FbDB2315678afecb367f032d93F642f64180aa3",
+		Input: "0xa9059cbb0000000000000000000000002857d75d6f42052ee415396ef1989c96b0768c7c00000000000000000000000000000000000000000000000000000000447bd088",
+	})
+	if call == nil {
+		t.Fatalf("Expected decoded call")
+	}
+	if call.Summary != "USDC.transfer(_to, _value)" {
+		t.Errorf("Unexpected summary '%s'", call.Summary)
+	}
+
+	// An ABI passed with the request comes before the registered one
+	abis, err := parseContractABIs(ctx, overloadedContractABI, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if requestABI := abis.get(ctx, common.HexToAddress(contract.Address)); requestABI == nil || requestABI.Methods["safeTransferFrom"].Name == "" {
+		t.Errorf("Expected the ABI of the request, got %v", requestABI)
+	}
+
+	// Storage layouts and source artifacts saved with the contract are used when the request has none
+	var layout StorageLayout
+	if err := json.Unmarshal([]byte(erc20StorageLayout), &layout); err != nil {
+		t.Fatalf("Failed to unmarshal storage layout: %v", err)
+	}
+	_, err = SaveContract(ctx, SaveContractRequest{
+		Address:       contract.Address,
+		Name:          "USDC",
+		ContractABI:   contractABI,
+		StorageLayout: &layout,
+		SourceArtifact: &SourceArtifact{
+			DeployedBytecode:  "0x600100",
+			DeployedSourceMap: "0:4:0;9:3",
+			Sources:           []SourceFile{{ID: 0, Name: "USDC.sol", Content: "line one\nline two\n"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if saved := registeredStorageLayout(ctx, common.HexToAddress(contract.Address)); saved == nil || len(saved.Storage) != 5 {
+		t.Errorf("Expected the saved storage layout, got %v", saved)
+	}
+	to := common.HexToAddress(contract.Address)
+	mappers := make(map[common.Address]*sourceMapper)
+	addRegisteredSourceMappers(ctx, mappers, callTracerFrame{Type: "CALL", Calls: []callTracerFrame{{Type: "CALL", To: &to}}})
+	mapper, ok := mappers[to]
+	if !ok || len(mappers) != 1 {
+		t.Fatalf("Expected the source mapper of the saved contract, got %v", mappers)
+	}
+	if location, ok := mapper.lookup(2); !ok || location.File != "USDC.sol" || location.Line != 2 {
+		t.Errorf("Expected line 2 of USDC.sol, got %v", location)
+	}
+
+	if err := DeleteContract(ctx, DeleteContractRequest{Address: contract.Address}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err := GetContract(ctx, GetContractRequest{Address: contract.Address}); err == nil {
+		t.Errorf("Expected deleted contract to be missing")
+	}
+}
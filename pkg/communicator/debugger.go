@@ -0,0 +1,319 @@
+package communicator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	DebugFindCall   = "call"   // Next call or create opcode
+	DebugFindReturn = "return" // Last step of the frame the search starts from
+	DebugFindOpcode = "opcode" // Next step with the given opcode
+
+	defaultDebugPageSize = 50
+	maxDebugPageSize     = 1000
+
+	// Struct logs with memory are large, only the most recently used traces are kept
+	maxCachedDebugTraces = 8
+)
+
+var debugTraces = &debugTraceCache{
+	traces: make(map[string]*debugTrace),
+}
+
+type GetDebugStepsRequest struct {
+	Hash   string `json:"hash"`
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+
+	// Compiler artifacts keyed by contract address, kept with the cached trace for the later requests.
+	// Artifacts of the contracts missing here are taken from the registries.
+	Artifacts map[string]SourceArtifact `json:"artifacts"`
+}
+
+type GetDebugStepsResponse struct {
+	TotalSteps  int         `json:"total_steps"`
+	GasUsed     uint64      `json:"gas_used"`
+	Failed      bool        `json:"failed"`
+	ReturnValue string      `json:"return_value"`
+	Steps       []DebugStep `json:"steps"`
+}
+
+type FindDebugStepRequest struct {
+	Hash      string `json:"hash"`
+	From      int    `json:"from"`
+	Kind      string `json:"kind"`
+	Opcode    string `json:"opcode"`
+	Backwards bool   `json:"backwards"`
+
+	Artifacts map[string]SourceArtifact `json:"artifacts"`
+}
+
+type FindDebugStepResponse struct {
+	Found bool       `json:"found"`
+	Step  *DebugStep `json:"step,omitempty"`
+}
+
+type DebugStep struct {
+	Index   int      `json:"index"`
+	Pc      uint64   `json:"pc"`
+	Opcode  string   `json:"opcode"`
+	Gas     uint64   `json:"gas"`
+	GasCost uint64   `json:"gas_cost"`
+	Depth   int      `json:"depth"`
+	Address string   `json:"address"` // Address of the executed code, empty for init code
+	Error   string   `json:"error,omitempty"`
+	Stack   []string `json:"stack"`
+	Memory  []string `json:"memory"`
+
+	// Storage slot written by the step
+	StorageChange *DebugStorageChange `json:"storage_change,omitempty"`
+
+	Source *SourceLocation `json:"source,omitempty"`
+}
+
+type DebugStorageChange struct {
+	Slot  string `json:"slot"`
+	Value string `json:"value"`
+}
+
+type debugTrace struct {
+	result  structLogResult
+	frames  []*structLogFrame // Frame executing each step
+	mappers map[common.Address]*sourceMapper
+}
+
+type debugTraceCache struct {
+	mu     sync.Mutex
+	traces map[string]*debugTrace
+	order  []string // Least recently used first
+}
+
+func GetDebugSteps(ctx context.Context, req GetDebugStepsRequest) (GetDebugStepsResponse, error) {
+	return getDebugSteps(ctx, req)
+}
+
+func getDebugSteps(ctx context.Context, req GetDebugStepsRequest) (GetDebugStepsResponse, error) {
+	trace, err := getDebugTrace(ctx, req.Hash, req.Artifacts)
+	if err != nil {
+		return GetDebugStepsResponse{}, err
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultDebugPageSize
+	}
+	limit = min(limit, maxDebugPageSize)
+	if req.Offset < 0 {
+		return GetDebugStepsResponse{}, fmt.Errorf("invalid offset %d", req.Offset)
+	}
+
+	response := GetDebugStepsResponse{
+		TotalSteps:  len(trace.result.StructLogs),
+		GasUsed:     trace.result.Gas,
+		Failed:      trace.result.Failed,
+		ReturnValue: trace.result.ReturnValue,
+		Steps:       []DebugStep{},
+	}
+	for i := req.Offset; i < len(trace.result.StructLogs) && i < req.Offset+limit; i++ {
+		response.Steps = append(response.Steps, trace.step(i))
+	}
+
+	return response, nil
+}
+
+func FindDebugStep(ctx context.Context, req FindDebugStepRequest) (FindDebugStepResponse, error) {
+	return findDebugStep(ctx, req)
+}
+
+func findDebugStep(ctx context.Context, req FindDebugStepRequest) (FindDebugStepResponse, error) {
+	trace, err := getDebugTrace(ctx, req.Hash, req.Artifacts)
+	if err != nil {
+		return FindDebugStepResponse{}, err
+	}
+	logs := trace.result.StructLogs
+	if req.From < 0 || req.From >= len(logs) {
+		return FindDebugStepResponse{}, fmt.Errorf("step %d is out of range, the trace has %d steps", req.From, len(logs))
+	}
+
+	var match func(i int) bool
+	switch req.Kind {
+	case DebugFindCall:
+		match = func(i int) bool {
+			return isCallOp(logs[i].Op)
+		}
+	case DebugFindReturn:
+		frame := trace.frames[req.From]
+		match = func(i int) bool {
+			if trace.frames[i] != frame {
+				return false
+			}
+			return i+1 == len(logs) || logs[i+1].Depth < logs[i].Depth
+		}
+	case DebugFindOpcode:
+		opcode := strings.ToUpper(req.Opcode)
+		if opcode == "" {
+			return FindDebugStepResponse{}, fmt.Errorf("opcode is required")
+		}
+		match = func(i int) bool {
+			return logs[i].Op == opcode
+		}
+	default:
+		return FindDebugStepResponse{}, fmt.Errorf("unknown search kind %q", req.Kind)
+	}
+
+	step := 1
+	if req.Backwards {
+		step = -1
+	}
+	for i := req.From + step; i >= 0 && i < len(logs); i += step {
+		if match(i) {
+			found := trace.step(i)
+			return FindDebugStepResponse{Found: true, Step: &found}, nil
+		}
+	}
+
+	return FindDebugStepResponse{}, nil
+}
+
+// getDebugTrace returns the cached struct log trace of the transaction, it's fetched on the first request.
+func getDebugTrace(ctx context.Context, hash string, artifacts map[string]SourceArtifact) (*debugTrace, error) {
+	mappers, err := parseSourceArtifacts(ctx, artifacts)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeAddress := GetNodeAddress(ctx)
+	key := nodeAddress + "/" + common.HexToHash(hash).Hex()
+	if trace, ok := debugTraces.get(key, mappers); ok {
+		return trace, nil
+	}
+
+	client, err := rpc.DialContext(ctx, nodeAddress)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to connect to Ethereum client", slog.Any("err", err))
+		return nil, err
+	}
+	defer client.Close()
+
+	result, callFrame, err := traceStructLogs(ctx, client, common.HexToHash(hash), map[string]interface{}{
+		"enableMemory":   true,
+		"disableStorage": true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	addRegisteredSourceMappers(ctx, mappers, callFrame)
+
+	trace := &debugTrace{
+		result:  result,
+		frames:  make([]*structLogFrame, len(result.StructLogs)),
+		mappers: mappers,
+	}
+	walkStructLogs(result.StructLogs, &callFrame, func(index int, frame *structLogFrame) {
+		trace.frames[index] = frame
+	})
+	debugTraces.add(key, trace)
+
+	return trace, nil
+}
+
+func (t *debugTrace) step(index int) DebugStep {
+	log := t.result.StructLogs[index]
+	step := DebugStep{
+		Index:   index,
+		Pc:      log.Pc,
+		Opcode:  log.Op,
+		Gas:     log.Gas,
+		GasCost: log.GasCost,
+		Depth:   log.Depth,
+		Error:   log.Error,
+		Stack:   log.Stack,
+		Memory:  log.Memory,
+	}
+	if step.Stack == nil {
+		step.Stack = []string{}
+	}
+	if step.Memory == nil {
+		step.Memory = []string{}
+	}
+
+	// The stack is ordered from bottom to top, SSTORE takes the slot from the top
+	if log.Op == "SSTORE" && len(log.Stack) >= 2 {
+		step.StorageChange = &DebugStorageChange{
+			Slot:  common.HexToHash(log.Stack[len(log.Stack)-1]).Hex(),
+			Value: common.HexToHash(log.Stack[len(log.Stack)-2]).Hex(),
+		}
+	}
+
+	address, ok := t.frames[index].codeAddress()
+	if !ok {
+		return step
+	}
+	step.Address = address.Hex()
+	if mapper, ok := t.mappers[address]; ok {
+		if location, ok := mapper.lookup(log.Pc); ok {
+			step.Source = &location
+		}
+	}
+
+	return step
+}
+
+// get returns the cached trace, artifacts provided later are added to the source mappers of the trace.
+func (c *debugTraceCache) get(key string, mappers map[common.Address]*sourceMapper) (*debugTrace, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	trace, ok := c.traces[key]
+	if !ok {
+		return nil, false
+	}
+	c.touch(key)
+
+	if len(mappers) > 0 {
+		merged := make(map[common.Address]*sourceMapper, len(trace.mappers)+len(mappers))
+		for address, mapper := range trace.mappers {
+			merged[address] = mapper
+		}
+		for address, mapper := range mappers {
+			merged[address] = mapper
+		}
+		trace = &debugTrace{
+			result:  trace.result,
+			frames:  trace.frames,
+			mappers: merged,
+		}
+		c.traces[key] = trace
+	}
+
+	return trace, true
+}
+
+func (c *debugTraceCache) add(key string, trace *debugTrace) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.traces[key] = trace
+	c.touch(key)
+	for len(c.order) > maxCachedDebugTraces {
+		delete(c.traces, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+func (c *debugTraceCache) touch(key string) {
+	for i, existing := range c.order {
+		if existing == key {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+	c.order = append(c.order, key)
+}
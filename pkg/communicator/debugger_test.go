@@ -0,0 +1,162 @@
+package communicator
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// Struct log and call trace of a contract calling a token which writes its storage
+	debuggerStructLogs = `{
+		"gas": 21102,
+		"failed": false,
+		"returnValue": "",
+		"structLogs": [
+			{"pc": 0, "op": "PUSH1", "gas": 1000, "gasCost": 3, "depth": 1, "stack": []},
+			{"pc": 2, "op": "CALL", "gas": 997, "gasCost": 500, "depth": 1, "stack": ["0x1"]},
+			{"pc": 0, "op": "PUSH1", "gas": 400, "gasCost": 3, "depth": 2, "stack": []},
+			{"pc": 2, "op": "SSTORE", "gas": 397, "gasCost": 100, "depth": 2, "stack": ["0x2a", "0x1"]},
+			{"pc": 3, "op": "STOP", "gas": 297, "gasCost": 0, "depth": 2, "stack": []},
+			{"pc": 3, "op": "POP", "gas": 900, "gasCost": 2, "depth": 1, "stack": ["0x1"]},
+			{"pc": 4, "op": "STOP", "gas": 898, "gasCost": 0, "depth": 1, "stack": []}
+		]
+	}`
+	debuggerCallTrace = `{
+		"type": "CALL",
+		"from": "0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266",
+		"to": "0xe7f1725e7734ce288f8367e1bb143e90bb3f0512",
+		"calls": [{
+			"type": "CALL",
+			"from": "0xe7f1725e7734ce288f8367e1bb143e90bb3f0512",
+			"to": "0x5fbdb2315678afecb367f032d93f642f64180aa3"
+		}]
+	}`
+)
+
+func TestDebugger(t *testing.T) {
+	var result structLogResult
+	if err := json.Unmarshal([]byte(debuggerStructLogs), &result); err != nil {
+		t.Fatalf("Failed to unmarshal struct logs: %v", err)
+	}
+	var frame callTracerFrame
+	if err := json.Unmarshal([]byte(debuggerCallTrace), &frame); err != nil {
+		t.Fatalf("Failed to unmarshal trace: %v", err)
+	}
+
+	// Seed the cache so the trace isn't fetched from a node
+	ctx := SetNodeAddress(context.Background(), "http://debugger.test")
+	hash := "0x1b2d3f4e5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d"
+	trace := &debugTrace{
+		result: result,
+		frames: make([]*structLogFrame, len(result.StructLogs)),
+	}
+	walkStructLogs(result.StructLogs, &frame, func(index int, frame *structLogFrame) {
+		trace.frames[index] = frame
+	})
+	debugTraces.add("http://debugger.test/"+common.HexToHash(hash).Hex(), trace)
+
+	steps, err := GetDebugSteps(ctx, GetDebugStepsRequest{
+		Hash:   hash,
+		Offset: 2,
+		Limit:  2,
+		Artifacts: map[string]SourceArtifact{
+			"0x5FbDB2315678afecb367f032d93F642f64180aa3": {
+				DeployedBytecode:  "0x602a5500",
+				DeployedSourceMap: "0:4:0;9:3",
+				Sources:           []SourceFile{{ID: 0, Name: "Token.sol", Content: "line one\nline two\n"}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if steps.TotalSteps != 7 || len(steps.Steps) != 2 {
+		t.Fatalf("Unexpected steps %v", steps)
+	}
+	sstore := steps.Steps[1]
+	if sstore.Address != "0x5FbDB2315678afecb367f032d93F642f64180aa3" || sstore.StorageChange == nil {
+		t.Fatalf("Unexpected SSTORE step %v", sstore)
+	}
+	if sstore.StorageChange.Slot != common.HexToHash("0x1").Hex() || sstore.StorageChange.Value != common.HexToHash("0x2a").Hex() {
+		t.Errorf("Unexpected storage change %v", sstore.StorageChange)
+	}
+	if sstore.Source == nil || sstore.Source.File != "Token.sol" || sstore.Source.Line != 2 {
+		t.Errorf("Unexpected source location %v", sstore.Source)
+	}
+
+	testCases := []struct {
+		name     string
+		req      FindDebugStepRequest
+		expected int
+	}{
+		{name: "next call", req: FindDebugStepRequest{From: 0, Kind: DebugFindCall}, expected: 1},
+		{name: "return of callee", req: FindDebugStepRequest{From: 2, Kind: DebugFindReturn}, expected: 4},
+		{name: "return of root", req: FindDebugStepRequest{From: 1, Kind: DebugFindReturn}, expected: 6},
+		{name: "opcode", req: FindDebugStepRequest{From: 0, Kind: DebugFindOpcode, Opcode: "sstore"}, expected: 3},
+		{name: "opcode backwards", req: FindDebugStepRequest{From: 5, Kind: DebugFindOpcode, Opcode: "PUSH1", Backwards: true}, expected: 2},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.req.Hash = hash
+			found, err := FindDebugStep(ctx, tc.req)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !found.Found || found.Step.Index != tc.expected {
+				t.Errorf("Expected step %d, got %v", tc.expected, found.Step)
+			}
+		})
+	}
+}
+
+// debugTestNode serves the struct log and the call trace of every transaction
+type debugTestNode struct{}
+
+func (debugTestNode) TraceTransaction(hash common.Hash, config map[string]interface{}) json.RawMessage {
+	if config["tracer"] == "callTracer" {
+		return json.RawMessage(debuggerCallTrace)
+	}
+	return json.RawMessage(debuggerStructLogs)
+}
+
+func TestDebuggerRegisteredArtifacts(t *testing.T) {
+	registry, err := OpenContractRegistry(filepath.Join(t.TempDir(), "contracts.json"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	SetContractRegistry(registry)
+	t.Cleanup(func() {
+		SetContractRegistry(nil)
+	})
+
+	ctx := newTestNode(t, map[string]interface{}{"debug": debugTestNode{}})
+	_, err = SaveContract(ctx, SaveContractRequest{
+		Address:     "0x5FbDB2315678afecb367f032d93F642f64180aa3",
+		ContractABI: contractABI,
+		SourceArtifact: &SourceArtifact{
+			DeployedBytecode:  "0x602a5500",
+			DeployedSourceMap: "0:4:0;9:3",
+			Sources:           []SourceFile{{ID: 0, Name: "Token.sol", Content: "line one\nline two\n"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The request has no artifacts, the token is mapped with the saved one
+	steps, err := GetDebugSteps(ctx, GetDebugStepsRequest{
+		Hash:   "0x1b2d3f4e5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d",
+		Offset: 3,
+		Limit:  1,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(steps.Steps) != 1 || steps.Steps[0].Source == nil || steps.Steps[0].Source.File != "Token.sol" || steps.Steps[0].Source.Line != 2 {
+		t.Errorf("Expected the SSTORE step at Token.sol:2, got %v", steps.Steps)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
@@ -13,11 +12,18 @@ import (
 type DecodeContractCallDataRequest struct {
 	ContractABI string `json:"contract_abi"`
 	InputData   string `json:"input_data"`
+
+	// Used to look up the registered ABI if ContractABI is empty
+	ContractAddress string `json:"contract_address"`
 }
 
 type DecodeContractCallDataResponse struct {
 	FunctionName string                 `json:"function_name"`
+	Signature    string                 `json:"signature"`
 	Args         map[string]interface{} `json:"args"`
+
+	// Set if the function was guessed from the signature database instead of a known ABI
+	Inferred bool `json:"inferred"`
 }
 
 func DecodeContractCallData(ctx context.Context, req DecodeContractCallDataRequest) (DecodeContractCallDataResponse, error) {
@@ -27,40 +33,55 @@ func DecodeContractCallData(ctx context.Context, req DecodeContractCallDataReque
 func decodeContractCallData(ctx context.Context, req DecodeContractCallDataRequest) (DecodeContractCallDataResponse, error) {
 	data := common.FromHex(req.InputData)
 
-	// Parse the ABI
-	parsedABI, err := abi.JSON(strings.NewReader(req.ContractABI))
-	if err != nil {
-		slog.ErrorContext(ctx, "Failed to parse contract ABI", slog.Any("err", err))
-		return DecodeContractCallDataResponse{}, fmt.Errorf("failed to parse ABI: %v", err)
+	parsedABI, abiErr := resolveContractABI(ctx, req.ContractABI, common.HexToAddress(req.ContractAddress))
+	if abiErr == nil {
+		method, args, err := decodeCallData(parsedABI, data)
+		if err == nil {
+			return DecodeContractCallDataResponse{
+				FunctionName: method.Name,
+				Signature:    method.Sig,
+				Args:         args,
+			}, nil
+		}
+		abiErr = err
+	}
+
+	// Fall back to the signature database if the ABI is unknown or doesn't have the function
+	method, args, ok := inferCallData(ctx, data)
+	if !ok {
+		slog.InfoContext(ctx, "Failed to decode contract call data", slog.Any("err", abiErr))
+		return DecodeContractCallDataResponse{}, abiErr
+	}
+	return DecodeContractCallDataResponse{
+		FunctionName: method.Name,
+		Signature:    method.Sig,
+		Args:         args,
+		Inferred:     true,
+	}, nil
+}
+
+// decodeCallData matches the function selector of the call data against the methods of the ABI and decodes the arguments.
+// Callers log the error, usually there is a fallback to try first.
+func decodeCallData(parsedABI abi.ABI, data []byte) (abi.Method, map[string]interface{}, error) {
+	if len(data) < 4 {
+		return abi.Method{}, nil, fmt.Errorf("call data is too short: %d bytes", len(data))
 	}
 
 	// Extract the function selector (first 4 bytes)
 	selector := data[:4]
 	payload := data[4:]
 
-	var response = DecodeContractCallDataResponse{
-		FunctionName: "",
-		Args:         make(map[string]interface{}),
-	}
 	// Try to match it against all functions
 	for name, method := range parsedABI.Methods {
 		if string(method.ID) == string(selector) {
-			response.FunctionName = name
-
 			// Decode the parameters
-			args := make(map[string]interface{})
-			if err := method.Inputs.UnpackIntoMap(args, payload); err != nil {
-				slog.ErrorContext(ctx, "Failed to decode contract call data", slog.Any("function", name), slog.Any("err", err))
-				return DecodeContractCallDataResponse{}, fmt.Errorf("failed to decode args: %v", err)
-			}
-
-			for k, v := range args {
-				response.Args[k] = v
+			args, err := unpackToJSON(method.Inputs, payload, "input")
+			if err != nil {
+				return abi.Method{}, nil, fmt.Errorf("failed to decode args of %s: %v", name, err)
 			}
-			return response, nil
+			return method, args, nil
 		}
 	}
 
-	slog.ErrorContext(ctx, "No matching function found for selector", slog.Any("selector", selector))
-	return DecodeContractCallDataResponse{}, fmt.Errorf("no matching function found for selector %x", selector)
+	return abi.Method{}, nil, fmt.Errorf("no matching function found for selector %x", selector)
 }
@@ -25,6 +25,6 @@ func TestDecodeContractCallData(t *testing.T) {
 
 	// Check the result
 	if result.FunctionName != "transfer" { // Replace with the expected result
-		t.Errorf("Expected 'expectedResult', got '%s'", result)
+		t.Errorf("Expected 'expectedResult', got '%s'", result.FunctionName)
 	}
 }
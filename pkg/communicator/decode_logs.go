@@ -0,0 +1,173 @@
+package communicator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+type DecodeLogsRequest struct {
+	// ABI used for every log which has no address specific ABI
+	ContractABI string `json:"contract_abi"`
+
+	// ABIs keyed by contract address
+	ContractABIs map[string]string `json:"contract_abis"`
+
+	// Logs are fetched from the receipt of this transaction if it's set
+	TransactionHash string `json:"transaction_hash"`
+	Logs            []Log  `json:"logs"`
+}
+
+type DecodeLogsResponse struct {
+	Logs []DecodedLog `json:"logs"`
+}
+
+type DecodedLog struct {
+	Log       Log                    `json:"log"`
+	EventName string                 `json:"event_name"`
+	Signature string                 `json:"signature"`
+	Indexed   map[string]interface{} `json:"indexed"`
+	Data      map[string]interface{} `json:"data"`
+	Error     string                 `json:"error,omitempty"`
+
+	// Set if the event was guessed from the signature database instead of a known ABI
+	Inferred bool `json:"inferred"`
+}
+
+func DecodeLogs(ctx context.Context, req DecodeLogsRequest) (DecodeLogsResponse, error) {
+	return decodeLogs(ctx, req)
+}
+
+func decodeLogs(ctx context.Context, req DecodeLogsRequest) (DecodeLogsResponse, error) {
+	abis, err := parseContractABIs(ctx, req.ContractABI, req.ContractABIs)
+	if err != nil {
+		return DecodeLogsResponse{}, err
+	}
+
+	logs, err := getLogsToDecode(ctx, req)
+	if err != nil {
+		return DecodeLogsResponse{}, err
+	}
+
+	response := DecodeLogsResponse{
+		Logs: make([]DecodedLog, 0, len(logs)),
+	}
+	for _, log := range logs {
+		decoded := decodeLog(ctx, abis.get(ctx, log.Address), log)
+		if decoded.EventName == "" {
+			if inferred, ok := inferLog(ctx, log); ok {
+				decoded = inferred
+			}
+		}
+		response.Logs = append(response.Logs, decoded)
+	}
+
+	return response, nil
+}
+
+func getLogsToDecode(ctx context.Context, req DecodeLogsRequest) ([]*types.Log, error) {
+	if req.TransactionHash == "" {
+		logs := make([]*types.Log, 0, len(req.Logs))
+		for _, log := range req.Logs {
+			logs = append(logs, toTypesLog(log))
+		}
+		return logs, nil
+	}
+
+	client, err := ethclient.DialContext(ctx, GetNodeAddress(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to connect to Ethereum client", slog.Any("err", err))
+		return nil, err
+	}
+
+	receipt, err := client.TransactionReceipt(ctx, common.HexToHash(req.TransactionHash))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get transaction receipt", slog.Any("hash", req.TransactionHash), slog.Any("err", err))
+		return nil, fmt.Errorf("failed to get transaction receipt: %v", err)
+	}
+
+	return receipt.Logs, nil
+}
+
+func decodeLog(ctx context.Context, contractABI *abi.ABI, log *types.Log) DecodedLog {
+	decoded := DecodedLog{
+		Log:     parseLog(log),
+		Indexed: make(map[string]interface{}),
+		Data:    make(map[string]interface{}),
+	}
+	if contractABI == nil {
+		decoded.Error = "no ABI found for contract"
+		return decoded
+	}
+	if len(log.Topics) == 0 {
+		decoded.Error = "anonymous events can't be decoded"
+		return decoded
+	}
+
+	event, err := contractABI.EventByID(log.Topics[0])
+	if err != nil {
+		decoded.Error = fmt.Sprintf("no matching event found for topic %s", log.Topics[0].Hex())
+		return decoded
+	}
+	decoded.EventName = event.Name
+	decoded.Signature = event.Sig
+
+	var indexed abi.Arguments
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if len(indexed) != len(log.Topics)-1 {
+		decoded.Error = fmt.Sprintf("expected %d indexed topics, got %d", len(indexed), len(log.Topics)-1)
+		return decoded
+	}
+	// Topics are parsed one by one as unnamed arguments would collide in the map
+	for j, input := range indexed {
+		topic := make(map[string]interface{})
+		if err := abi.ParseTopicsIntoMap(topic, abi.Arguments{input}, log.Topics[j+1:j+2]); err != nil {
+			slog.ErrorContext(ctx, "Failed to decode indexed event arguments", slog.Any("event", event.Name), slog.Any("err", err))
+			decoded.Error = fmt.Sprintf("failed to decode topics: %v", err)
+			return decoded
+		}
+		name := input.Name
+		if name == "" {
+			name = fmt.Sprintf("topic_%d", j)
+		}
+		decoded.Indexed[name] = toJSONValue(input.Type, topic[input.Name])
+	}
+
+	data, err := unpackToJSON(event.Inputs.NonIndexed(), log.Data, "data")
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to decode event data", slog.Any("event", event.Name), slog.Any("err", err))
+		decoded.Error = fmt.Sprintf("failed to decode data: %v", err)
+		return decoded
+	}
+	decoded.Data = data
+
+	return decoded
+}
+
+func toTypesLog(log Log) *types.Log {
+	topics := make([]common.Hash, 0, len(log.Topics))
+	for _, topic := range log.Topics {
+		topics = append(topics, common.HexToHash(topic))
+	}
+
+	return &types.Log{
+		Address:     common.HexToAddress(log.Address),
+		Topics:      topics,
+		Data:        common.FromHex(log.Data),
+		BlockNumber: log.BlockNumber,
+		BlockHash:   common.HexToHash(log.BlockHash),
+		TxHash:      common.HexToHash(log.TransactionHash),
+		TxIndex:     log.TransactionIndex,
+		Index:       log.LogIndex,
+		Removed:     log.Removed,
+	}
+}
@@ -0,0 +1,43 @@
+package communicator
+
+import (
+	"context"
+	"testing"
+)
+
+func TestDecodeLogs(t *testing.T) {
+	// Transfer(from, to, value) emitted by an ERC20 token
+	transferLog := Log{
+		Address: "0x514910771AF9Ca656af840dff83E8264EcF986CA",
+		Topics: []string{
+			"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+			"0x0000000000000000000000009491a3757a98e53be0d1c14834a6e2da0b4dc527",
+			"0x0000000000000000000000002857d75d6f42052ee415396ef1989c96b0768c7c",
+		},
+		Data: "0x00000000000000000000000000000000000000000000000000000000447bd088",
+	}
+
+	result, err := decodeLogs(context.Background(), DecodeLogsRequest{
+		ContractABIs: map[string]string{
+			"0x514910771af9ca656af840dff83e8264ecf986ca": contractABI,
+		},
+		Logs: []Log{transferLog},
+	})
+	if err != nil {
+		t.Fatalf("Failed to decode logs: %v", err)
+	}
+
+	if len(result.Logs) != 1 {
+		t.Fatalf("Expected 1 decoded log, got %d", len(result.Logs))
+	}
+	decoded := result.Logs[0]
+	if decoded.EventName != "Transfer" {
+		t.Errorf("Expected 'Transfer', got '%s' (%s)", decoded.EventName, decoded.Error)
+	}
+	if decoded.Indexed["to"] != "0x2857D75d6F42052Ee415396ef1989C96B0768C7C" {
+		t.Errorf("Unexpected indexed 'to' argument %v", decoded.Indexed["to"])
+	}
+	if decoded.Data["value"] != "1148965000" {
+		t.Errorf("Unexpected 'value' argument %v", decoded.Data["value"])
+	}
+}
@@ -0,0 +1,221 @@
+package communicator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const (
+	// Interval mined chains might take a while to include the creation
+	deployReceiptTimeout = 5 * time.Minute
+	receiptPollInterval  = 500 * time.Millisecond
+)
+
+type DeployContractRequest struct {
+	// Creation bytecode, or the name of a registered artifact (e.g. Token or contracts/Token.sol:Token)
+	Bytecode string `json:"bytecode"`
+	Artifact string `json:"artifact"`
+
+	// Needed for constructor arguments, taken from the artifact if empty
+	ContractABI     string    `json:"contract_abi"`
+	ConstructorArgs Arguments `json:"constructor_args"`
+
+	Value         string `json:"value"`       // wei, decimal or 0x prefixed hex
+	Signer        string `json:"signer"`      // name or address of a registered or node account
+	PrivateKeyHex string `json:"private_key"` // used if no signer is set, without "0x" prefix
+}
+
+type DeployContractResponse struct {
+	TransactionHash string  `json:"transaction_hash"`
+	ContractAddress string  `json:"contract_address"`
+	Receipt         Receipt `json:"receipt"`
+}
+
+func DeployContract(ctx context.Context, req DeployContractRequest) (DeployContractResponse, error) {
+	return deployContract(ctx, req)
+}
+
+func deployContract(ctx context.Context, req DeployContractRequest) (DeployContractResponse, error) {
+	bytecode, contractABI, err := getDeployBytecode(ctx, req)
+	if err != nil {
+		return DeployContractResponse{}, err
+	}
+
+	value, err := parseWei("value", req.Value)
+	if err != nil {
+		return DeployContractResponse{}, err
+	}
+	if value == nil {
+		value = new(big.Int)
+	}
+	if value.Sign() > 0 && contractABI != nil && !contractABI.Constructor.IsPayable() {
+		return DeployContractResponse{}, fmt.Errorf("constructor is not payable")
+	}
+
+	client, err := ethclient.DialContext(ctx, GetNodeAddress(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to connect to Ethereum client", slog.Any("err", err))
+		return DeployContractResponse{}, err
+	}
+	defer client.Close()
+
+	sender, err := resolveSigner(ctx, client, req.Signer, req.PrivateKeyHex)
+	if err != nil {
+		return DeployContractResponse{}, err
+	}
+	fromAddress := sender.address
+
+	nonce, err := client.PendingNonceAt(ctx, fromAddress)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get nonce", slog.Any("err", err))
+		return DeployContractResponse{}, fmt.Errorf("failed to get nonce: %v", err)
+	}
+	gasPrice, err := client.SuggestGasPrice(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to suggest gas price", slog.Any("err", err))
+		return DeployContractResponse{}, fmt.Errorf("failed to suggest gas price: %v", err)
+	}
+	gasLimit, err := client.EstimateGas(ctx, ethereum.CallMsg{
+		From:  fromAddress,
+		Value: value,
+		Data:  bytecode,
+	})
+	if err != nil {
+		if revertData, ok := revertDataFromError(err); ok {
+			revert := decodeRevert(contractABI, revertData)
+			return DeployContractResponse{}, fmt.Errorf("constructor reverted: %s", revert.Message)
+		}
+		slog.ErrorContext(ctx, "Failed to estimate gas", slog.Any("err", err))
+		return DeployContractResponse{}, fmt.Errorf("failed to estimate gas: %v", err)
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get chain ID", slog.Any("err", err))
+		return DeployContractResponse{}, fmt.Errorf("failed to get chain ID: %v", err)
+	}
+
+	hash, err := sender.sendTransaction(ctx, client, chainID, &types.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: gasPrice,
+		Gas:      gasLimit,
+		Value:    value,
+		Data:     bytecode,
+	})
+	if err != nil {
+		return DeployContractResponse{}, err
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, deployReceiptTimeout)
+	defer cancel()
+	receipt, err := waitForReceipt(waitCtx, client, hash)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to wait for the deployment", slog.Any("hash", hash.Hex()), slog.Any("err", err))
+		return DeployContractResponse{}, fmt.Errorf("failed to wait for transaction %s: %v", hash.Hex(), err)
+	}
+
+	response := DeployContractResponse{
+		TransactionHash: hash.Hex(),
+		Receipt:         parseReceipt(receipt),
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return response, fmt.Errorf("deployment %s failed", response.TransactionHash)
+	}
+	response.ContractAddress = receipt.ContractAddress.Hex()
+
+	return response, nil
+}
+
+// getDeployBytecode returns the creation bytecode with the encoded constructor arguments appended.
+func getDeployBytecode(ctx context.Context, req DeployContractRequest) ([]byte, *abi.ABI, error) {
+	var contractABI *abi.ABI
+	if req.ContractABI != "" {
+		parsedABI, err := abi.JSON(strings.NewReader(req.ContractABI))
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to parse contract ABI", slog.Any("err", err))
+			return nil, nil, fmt.Errorf("failed to parse ABI: %v", err)
+		}
+		contractABI = &parsedABI
+	}
+
+	hexBytecode := req.Bytecode
+	switch {
+	case req.Bytecode != "" && req.Artifact != "":
+		return nil, nil, fmt.Errorf("either bytecode or artifact has to be set, not both")
+	case req.Artifact != "":
+		registry := getArtifactRegistry()
+		if registry == nil {
+			return nil, nil, fmt.Errorf("artifact registry is not configured")
+		}
+		loaded, err := registry.artifactByName(req.Artifact)
+		if err != nil {
+			return nil, nil, err
+		}
+		hexBytecode = loaded.bytecode
+		if contractABI == nil {
+			contractABI = &loaded.abi
+		}
+	case req.Bytecode == "":
+		return nil, nil, fmt.Errorf("bytecode or artifact is required")
+	}
+
+	if libraryPlaceholder.MatchString(hexBytecode) {
+		return nil, nil, fmt.Errorf("bytecode has unlinked libraries")
+	}
+	bytecode, err := hexutil.Decode("0x" + strings.TrimPrefix(hexBytecode, "0x"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid bytecode: %v", err)
+	}
+
+	if contractABI == nil {
+		if len(req.ConstructorArgs) > 0 {
+			return nil, nil, fmt.Errorf("constructor arguments need the contract ABI")
+		}
+		return bytecode, nil, nil
+	}
+	convertedArgs, err := convertArguments(contractABI.Constructor.Inputs, req.ConstructorArgs)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to convert constructor arguments", slog.Any("err", err))
+		return nil, nil, fmt.Errorf("failed to convert constructor arguments: %v", err)
+	}
+	args, err := contractABI.Constructor.Inputs.Pack(convertedArgs...)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to pack constructor arguments", slog.Any("err", err))
+		return nil, nil, fmt.Errorf("failed to pack constructor arguments: %v", err)
+	}
+
+	return append(bytecode, args...), contractABI, nil
+}
+
+// waitForReceipt polls the node until the transaction is mined or the context is done.
+func waitForReceipt(ctx context.Context, client *ethclient.Client, hash common.Hash) (*types.Receipt, error) {
+	ticker := time.NewTicker(receiptPollInterval)
+	defer ticker.Stop()
+
+	for {
+		receipt, err := client.TransactionReceipt(ctx, hash)
+		if err == nil {
+			return receipt, nil
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package communicator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const constructorABI = `[{"type":"constructor","stateMutability":"nonpayable","inputs":[{"name":"supply","type":"uint256"},{"name":"owner","type":"address"}]}]`
+
+func TestGetDeployBytecode(t *testing.T) {
+	bytecode, contractABI, err := getDeployBytecode(context.Background(), DeployContractRequest{
+		Bytecode:        "0x6080",
+		ContractABI:     constructorABI,
+		ConstructorArgs: Arguments{"1000", "0x5FbDB2315678afecb367f032d93F642f64180aa3"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if contractABI == nil {
+		t.Fatalf("Expected parsed ABI")
+	}
+	expected := "0x6080" +
+		"00000000000000000000000000000000000000000000000000000000000003e8" +
+		"0000000000000000000000005fbdb2315678afecb367f032d93f642f64180aa3"
+	if got := hexutil.Encode(bytecode); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	_, _, err = getDeployBytecode(context.Background(), DeployContractRequest{
+		Bytecode:        "0x6080",
+		ContractABI:     constructorABI,
+		ConstructorArgs: Arguments{"1000"},
+	})
+	if err == nil {
+		t.Errorf("Expected error for missing constructor argument")
+	}
+
+	_, _, err = getDeployBytecode(context.Background(), DeployContractRequest{
+		Bytecode: "0x73__$" + strings.Repeat("a", 34) + "$__",
+	})
+	if err == nil || !strings.Contains(err.Error(), "unlinked") {
+		t.Errorf("Expected unlinked library error, got %v", err)
+	}
+}
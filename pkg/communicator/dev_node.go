@@ -0,0 +1,503 @@
+package communicator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	DevNodeDialectHardhat = "hardhat"
+	DevNodeDialectAnvil   = "anvil"
+	DevNodeDialectGanache = "ganache"
+)
+
+// Snapshots taken through the API, a node only knows their IDs
+var devNodeSnapshots = &snapshotStore{
+	genesis:   make(map[string]string),
+	snapshots: make(map[string][]Snapshot),
+}
+
+type snapshotStore struct {
+	mu        sync.Mutex
+	genesis   map[string]string     // node address -> genesis hash of the chain the snapshots were taken on
+	snapshots map[string][]Snapshot // node address -> snapshots in the order they were taken
+}
+
+type Snapshot struct {
+	Name        string    `json:"name"`
+	ID          string    `json:"id"`
+	BlockNumber uint64    `json:"block_number"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type DevNodeStatus struct {
+	ClientVersion string     `json:"client_version"`
+	Dialect       string     `json:"dialect"`
+	BlockNumber   uint64     `json:"block_number"`
+	Timestamp     uint64     `json:"timestamp"`
+	Automine      *bool      `json:"automine,omitempty"` // not reported by Ganache
+	Snapshots     []Snapshot `json:"snapshots"`
+}
+
+// DevNodeResponse describes the head of the node after the operation.
+type DevNodeResponse struct {
+	Dialect     string `json:"dialect"`
+	BlockNumber uint64 `json:"block_number"`
+	Timestamp   uint64 `json:"timestamp"`
+}
+
+type MineRequest struct {
+	Blocks   uint64 `json:"blocks"`   // 1 if not set
+	Interval uint64 `json:"interval"` // seconds between the timestamps of the mined blocks
+}
+
+type TakeSnapshotRequest struct {
+	Name string `json:"name"` // the ID of the snapshot if not set
+}
+
+type RevertSnapshotRequest struct {
+	Snapshot string `json:"snapshot"` // name or ID
+}
+
+type RevertSnapshotResponse struct {
+	DevNodeResponse
+	// The reverted snapshot and the ones taken after it, the node invalidates them
+	Removed []Snapshot `json:"removed"`
+}
+
+type IncreaseTimeRequest struct {
+	Seconds uint64 `json:"seconds"`
+}
+
+type SetNextBlockTimestampRequest struct {
+	Timestamp uint64 `json:"timestamp"`
+}
+
+type SetAutomineRequest struct {
+	Enabled bool `json:"enabled"`
+}
+
+type SetIntervalMiningRequest struct {
+	Interval uint64 `json:"interval"` // seconds, 0 disables interval mining
+}
+
+type devNode struct {
+	rpcClient     *rpc.Client
+	client        *ethclient.Client
+	address       string
+	clientVersion string
+	dialect       string
+}
+
+// dialDevNode connects to the node of the request and detects its dialect from web3_clientVersion.
+func dialDevNode(ctx context.Context) (*devNode, error) {
+	address := GetNodeAddress(ctx)
+	rpcClient, err := rpc.DialContext(ctx, address)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to connect to Ethereum client", slog.Any("err", err))
+		return nil, err
+	}
+
+	var clientVersion string
+	if err := rpcClient.CallContext(ctx, &clientVersion, "web3_clientVersion"); err != nil {
+		rpcClient.Close()
+		slog.ErrorContext(ctx, "Failed to get client version", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to get client version: %v", err)
+	}
+	dialect := detectDevNodeDialect(clientVersion)
+	if dialect == "" {
+		rpcClient.Close()
+		return nil, fmt.Errorf("%s is not a Hardhat, Anvil or Ganache node", clientVersion)
+	}
+
+	return &devNode{
+		rpcClient:     rpcClient,
+		client:        ethclient.NewClient(rpcClient),
+		address:       address,
+		clientVersion: clientVersion,
+		dialect:       dialect,
+	}, nil
+}
+
+func detectDevNodeDialect(clientVersion string) string {
+	version := strings.ToLower(clientVersion)
+	switch {
+	case strings.Contains(version, "hardhat"):
+		return DevNodeDialectHardhat
+	case strings.Contains(version, "anvil"):
+		return DevNodeDialectAnvil
+	case strings.Contains(version, "ganache"), strings.Contains(version, "testrpc"):
+		return DevNodeDialectGanache
+	}
+	return ""
+}
+
+// syncSnapshots drops the snapshots of the node if it was restarted since they were taken, the new
+// chain reuses their IDs.
+func (n *devNode) syncSnapshots(ctx context.Context) error {
+	genesis, err := n.client.HeaderByNumber(ctx, big.NewInt(0))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get genesis block", slog.Any("err", err))
+		return fmt.Errorf("failed to get genesis block: %v", err)
+	}
+	devNodeSnapshots.setGenesis(n.address, genesis.Hash().Hex())
+	return nil
+}
+
+func (n *devNode) Close() {
+	n.rpcClient.Close()
+}
+
+func (n *devNode) call(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if err := n.rpcClient.CallContext(ctx, result, method, args...); err != nil {
+		slog.ErrorContext(ctx, "Dev node call failed", slog.Any("method", method), slog.Any("dialect", n.dialect), slog.Any("err", err))
+		return fmt.Errorf("%s failed: %v", method, err)
+	}
+	return nil
+}
+
+func (n *devNode) head(ctx context.Context) (DevNodeResponse, error) {
+	header, err := n.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get latest header", slog.Any("err", err))
+		return DevNodeResponse{}, fmt.Errorf("failed to get latest header: %v", err)
+	}
+	return DevNodeResponse{
+		Dialect:     n.dialect,
+		BlockNumber: header.Number.Uint64(),
+		Timestamp:   header.Time,
+	}, nil
+}
+
+func GetDevNode(ctx context.Context) (DevNodeStatus, error) {
+	return getDevNode(ctx)
+}
+
+func getDevNode(ctx context.Context) (DevNodeStatus, error) {
+	node, err := dialDevNode(ctx)
+	if err != nil {
+		return DevNodeStatus{}, err
+	}
+	defer node.Close()
+
+	head, err := node.head(ctx)
+	if err != nil {
+		return DevNodeStatus{}, err
+	}
+	if err := node.syncSnapshots(ctx); err != nil {
+		return DevNodeStatus{}, err
+	}
+	status := DevNodeStatus{
+		ClientVersion: node.clientVersion,
+		Dialect:       node.dialect,
+		BlockNumber:   head.BlockNumber,
+		Timestamp:     head.Timestamp,
+		Snapshots:     devNodeSnapshots.list(node.address),
+	}
+
+	if node.dialect != DevNodeDialectGanache {
+		var automine bool
+		if err := node.call(ctx, &automine, node.dialect+"_getAutomine"); err != nil {
+			return DevNodeStatus{}, err
+		}
+		status.Automine = &automine
+	}
+
+	return status, nil
+}
+
+func Mine(ctx context.Context, req MineRequest) (DevNodeResponse, error) {
+	return mine(ctx, req)
+}
+
+func mine(ctx context.Context, req MineRequest) (DevNodeResponse, error) {
+	node, err := dialDevNode(ctx)
+	if err != nil {
+		return DevNodeResponse{}, err
+	}
+	defer node.Close()
+
+	blocks := req.Blocks
+	if blocks == 0 {
+		blocks = 1
+	}
+
+	switch node.dialect {
+	case DevNodeDialectGanache:
+		for i := uint64(0); i < blocks; i++ {
+			if i > 0 && req.Interval > 0 {
+				if err := node.call(ctx, nil, "evm_increaseTime", req.Interval); err != nil {
+					return DevNodeResponse{}, err
+				}
+			}
+			if err := node.call(ctx, nil, "evm_mine"); err != nil {
+				return DevNodeResponse{}, err
+			}
+		}
+	default:
+		// Hardhat uses 1 second between the blocks if the interval isn't set
+		interval := req.Interval
+		if interval == 0 {
+			interval = 1
+		}
+		if err := node.call(ctx, nil, node.dialect+"_mine", hexutil.Uint64(blocks), hexutil.Uint64(interval)); err != nil {
+			return DevNodeResponse{}, err
+		}
+	}
+
+	return node.head(ctx)
+}
+
+func GetSnapshots(ctx context.Context) ([]Snapshot, error) {
+	return getSnapshots(ctx)
+}
+
+func getSnapshots(ctx context.Context) ([]Snapshot, error) {
+	node, err := dialDevNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer node.Close()
+
+	if err := node.syncSnapshots(ctx); err != nil {
+		return nil, err
+	}
+	return devNodeSnapshots.list(node.address), nil
+}
+
+func TakeSnapshot(ctx context.Context, req TakeSnapshotRequest) (Snapshot, error) {
+	return takeSnapshot(ctx, req)
+}
+
+func takeSnapshot(ctx context.Context, req TakeSnapshotRequest) (Snapshot, error) {
+	node, err := dialDevNode(ctx)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer node.Close()
+
+	if err := node.syncSnapshots(ctx); err != nil {
+		return Snapshot{}, err
+	}
+	name := strings.TrimSpace(req.Name)
+	if name != "" {
+		if _, ok := devNodeSnapshots.find(node.address, name); ok {
+			return Snapshot{}, fmt.Errorf("snapshot %q already exists", name)
+		}
+	}
+
+	var id string
+	if err := node.call(ctx, &id, "evm_snapshot"); err != nil {
+		return Snapshot{}, err
+	}
+	head, err := node.head(ctx)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if name == "" {
+		name = id
+	}
+
+	snapshot := Snapshot{
+		Name:        name,
+		ID:          id,
+		BlockNumber: head.BlockNumber,
+		CreatedAt:   time.Now().UTC(),
+	}
+	devNodeSnapshots.add(node.address, snapshot)
+	return snapshot, nil
+}
+
+func RevertSnapshot(ctx context.Context, req RevertSnapshotRequest) (RevertSnapshotResponse, error) {
+	return revertSnapshot(ctx, req)
+}
+
+func revertSnapshot(ctx context.Context, req RevertSnapshotRequest) (RevertSnapshotResponse, error) {
+	node, err := dialDevNode(ctx)
+	if err != nil {
+		return RevertSnapshotResponse{}, err
+	}
+	defer node.Close()
+
+	if err := node.syncSnapshots(ctx); err != nil {
+		return RevertSnapshotResponse{}, err
+	}
+	snapshot, ok := devNodeSnapshots.find(node.address, req.Snapshot)
+	if !ok {
+		return RevertSnapshotResponse{}, fmt.Errorf("unknown snapshot %q", req.Snapshot)
+	}
+
+	var reverted bool
+	if err := node.call(ctx, &reverted, "evm_revert", snapshot.ID); err != nil {
+		return RevertSnapshotResponse{}, err
+	}
+	// The snapshot is gone either way, e.g. the node was restarted since it was taken
+	removed := devNodeSnapshots.removeFrom(node.address, snapshot.ID)
+	nodeChainChanged(node.address)
+	if !reverted {
+		return RevertSnapshotResponse{}, fmt.Errorf("snapshot %q is no longer valid on the node", snapshot.Name)
+	}
+
+	head, err := node.head(ctx)
+	if err != nil {
+		return RevertSnapshotResponse{}, err
+	}
+	return RevertSnapshotResponse{
+		DevNodeResponse: head,
+		Removed:         removed,
+	}, nil
+}
+
+func IncreaseTime(ctx context.Context, req IncreaseTimeRequest) (DevNodeResponse, error) {
+	return increaseTime(ctx, req)
+}
+
+func increaseTime(ctx context.Context, req IncreaseTimeRequest) (DevNodeResponse, error) {
+	node, err := dialDevNode(ctx)
+	if err != nil {
+		return DevNodeResponse{}, err
+	}
+	defer node.Close()
+
+	if err := node.call(ctx, nil, "evm_increaseTime", req.Seconds); err != nil {
+		return DevNodeResponse{}, err
+	}
+	return node.head(ctx)
+}
+
+func SetNextBlockTimestamp(ctx context.Context, req SetNextBlockTimestampRequest) (DevNodeResponse, error) {
+	return setNextBlockTimestamp(ctx, req)
+}
+
+func setNextBlockTimestamp(ctx context.Context, req SetNextBlockTimestampRequest) (DevNodeResponse, error) {
+	node, err := dialDevNode(ctx)
+	if err != nil {
+		return DevNodeResponse{}, err
+	}
+	defer node.Close()
+
+	switch node.dialect {
+	case DevNodeDialectGanache:
+		// Ganache sets the clock in milliseconds, the next block uses it
+		err = node.call(ctx, nil, "evm_setTime", req.Timestamp*1000)
+	default:
+		err = node.call(ctx, nil, "evm_setNextBlockTimestamp", req.Timestamp)
+	}
+	if err != nil {
+		return DevNodeResponse{}, err
+	}
+	return node.head(ctx)
+}
+
+func SetAutomine(ctx context.Context, req SetAutomineRequest) (DevNodeResponse, error) {
+	return setAutomine(ctx, req)
+}
+
+func setAutomine(ctx context.Context, req SetAutomineRequest) (DevNodeResponse, error) {
+	node, err := dialDevNode(ctx)
+	if err != nil {
+		return DevNodeResponse{}, err
+	}
+	defer node.Close()
+
+	switch {
+	case node.dialect != DevNodeDialectGanache:
+		err = node.call(ctx, nil, "evm_setAutomine", req.Enabled)
+	case req.Enabled:
+		err = node.call(ctx, nil, "miner_start")
+	default:
+		err = node.call(ctx, nil, "miner_stop")
+	}
+	if err != nil {
+		return DevNodeResponse{}, err
+	}
+	return node.head(ctx)
+}
+
+func SetIntervalMining(ctx context.Context, req SetIntervalMiningRequest) (DevNodeResponse, error) {
+	return setIntervalMining(ctx, req)
+}
+
+func setIntervalMining(ctx context.Context, req SetIntervalMiningRequest) (DevNodeResponse, error) {
+	node, err := dialDevNode(ctx)
+	if err != nil {
+		return DevNodeResponse{}, err
+	}
+	defer node.Close()
+
+	switch node.dialect {
+	case DevNodeDialectHardhat:
+		// Hardhat expects milliseconds
+		err = node.call(ctx, nil, "evm_setIntervalMining", req.Interval*1000)
+	case DevNodeDialectAnvil:
+		err = node.call(ctx, nil, "evm_setIntervalMining", req.Interval)
+	default:
+		return DevNodeResponse{}, fmt.Errorf("interval mining is not supported by %s", node.dialect)
+	}
+	if err != nil {
+		return DevNodeResponse{}, err
+	}
+	return node.head(ctx)
+}
+
+// setGenesis records the chain of the node, the snapshots of another chain are dropped.
+func (s *snapshotStore) setGenesis(nodeAddress, genesisHash string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.genesis[nodeAddress] != genesisHash {
+		s.genesis[nodeAddress] = genesisHash
+		delete(s.snapshots, nodeAddress)
+	}
+}
+
+func (s *snapshotStore) list(nodeAddress string) []Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Snapshot{}, s.snapshots[nodeAddress]...)
+}
+
+func (s *snapshotStore) add(nodeAddress string, snapshot Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots[nodeAddress] = append(s.snapshots[nodeAddress], snapshot)
+}
+
+// find returns the snapshot by name, or by ID if no name matches.
+func (s *snapshotStore) find(nodeAddress, ref string) (Snapshot, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, snapshot := range s.snapshots[nodeAddress] {
+		if snapshot.Name == ref {
+			return snapshot, true
+		}
+	}
+	for _, snapshot := range s.snapshots[nodeAddress] {
+		if snapshot.ID == ref {
+			return snapshot, true
+		}
+	}
+	return Snapshot{}, false
+}
+
+// removeFrom drops the snapshot and the ones taken after it, as reverting invalidates them on the node.
+func (s *snapshotStore) removeFrom(nodeAddress, id string) []Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snapshots := s.snapshots[nodeAddress]
+	for i, snapshot := range snapshots {
+		if snapshot.ID == id {
+			s.snapshots[nodeAddress] = snapshots[:i:i]
+			return append([]Snapshot{}, snapshots[i:]...)
+		}
+	}
+	return []Snapshot{}
+}
@@ -0,0 +1,332 @@
+package communicator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Accounts impersonated through the API, they stay impersonated after a send
+var impersonatedAccounts = &impersonationStore{
+	accounts: make(map[string]map[common.Address]struct{}),
+}
+
+// Ganache names the state methods differently and only impersonates accounts unlocked on startup
+var ganacheStateMethods = map[string]string{
+	"setBalance":   "evm_setAccountBalance",
+	"setCode":      "evm_setAccountCode",
+	"setStorageAt": "evm_setAccountStorageAt",
+	"setNonce":     "evm_setAccountNonce",
+}
+
+type impersonationStore struct {
+	mu       sync.Mutex
+	accounts map[string]map[common.Address]struct{} // node address -> impersonated accounts
+}
+
+type AccountState struct {
+	Dialect  string `json:"dialect"`
+	Address  string `json:"address"`
+	Balance  string `json:"balance"`
+	Nonce    uint64 `json:"nonce"`
+	CodeSize int    `json:"code_size"`
+}
+
+type SetBalanceRequest struct {
+	Address string `json:"address"`
+	Balance string `json:"balance"` // wei, decimal or 0x prefixed hex
+}
+
+type SetCodeRequest struct {
+	Address string `json:"address"`
+	Code    string `json:"code"` // deployed bytecode, empty removes the code
+}
+
+type SetStorageAtRequest struct {
+	Address string `json:"address"`
+	Slot    string `json:"slot"`  // decimal or 0x prefixed hex
+	Value   string `json:"value"` // decimal or 0x prefixed hex, stored as a 32 byte word
+}
+
+type SetNonceRequest struct {
+	Address string `json:"address"`
+	Nonce   uint64 `json:"nonce"`
+}
+
+type ImpersonateAccountRequest struct {
+	Address string `json:"address"`
+}
+
+func (n *devNode) stateMethod(name string) (string, error) {
+	if n.dialect != DevNodeDialectGanache {
+		return n.dialect + "_" + name, nil
+	}
+	if method, ok := ganacheStateMethods[name]; ok {
+		return method, nil
+	}
+	return "", fmt.Errorf("%s is not supported by %s", name, n.dialect)
+}
+
+func (n *devNode) setState(ctx context.Context, name string, args ...interface{}) error {
+	method, err := n.stateMethod(name)
+	if err != nil {
+		return err
+	}
+	return n.call(ctx, nil, method, args...)
+}
+
+func (n *devNode) accountState(ctx context.Context, address common.Address) (AccountState, error) {
+	balance, err := n.client.BalanceAt(ctx, address, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get balance", slog.Any("address", address.Hex()), slog.Any("err", err))
+		return AccountState{}, fmt.Errorf("failed to get balance: %v", err)
+	}
+	nonce, err := n.client.NonceAt(ctx, address, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get nonce", slog.Any("address", address.Hex()), slog.Any("err", err))
+		return AccountState{}, fmt.Errorf("failed to get nonce: %v", err)
+	}
+	code, err := n.client.CodeAt(ctx, address, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get code", slog.Any("address", address.Hex()), slog.Any("err", err))
+		return AccountState{}, fmt.Errorf("failed to get code: %v", err)
+	}
+
+	return AccountState{
+		Dialect:  n.dialect,
+		Address:  address.Hex(),
+		Balance:  balance.String(),
+		Nonce:    nonce,
+		CodeSize: len(code),
+	}, nil
+}
+
+func parseDevNodeAddress(address string) (common.Address, error) {
+	if !common.IsHexAddress(address) {
+		return common.Address{}, fmt.Errorf("invalid address %q", address)
+	}
+	return common.HexToAddress(address), nil
+}
+
+func SetBalance(ctx context.Context, req SetBalanceRequest) (AccountState, error) {
+	return setBalance(ctx, req)
+}
+
+func setBalance(ctx context.Context, req SetBalanceRequest) (AccountState, error) {
+	address, err := parseDevNodeAddress(req.Address)
+	if err != nil {
+		return AccountState{}, err
+	}
+	balance, err := parseWei("balance", req.Balance)
+	if err != nil {
+		return AccountState{}, err
+	}
+	if balance == nil {
+		return AccountState{}, fmt.Errorf("balance is required")
+	}
+
+	node, err := dialDevNode(ctx)
+	if err != nil {
+		return AccountState{}, err
+	}
+	defer node.Close()
+
+	if err := node.setState(ctx, "setBalance", address, (*hexutil.Big)(balance)); err != nil {
+		return AccountState{}, err
+	}
+	return node.accountState(ctx, address)
+}
+
+func SetCode(ctx context.Context, req SetCodeRequest) (AccountState, error) {
+	return setCode(ctx, req)
+}
+
+func setCode(ctx context.Context, req SetCodeRequest) (AccountState, error) {
+	address, err := parseDevNodeAddress(req.Address)
+	if err != nil {
+		return AccountState{}, err
+	}
+	code, err := hexutil.Decode(ensure0xPrefix(req.Code))
+	if err != nil {
+		return AccountState{}, fmt.Errorf("invalid code: %v", err)
+	}
+
+	node, err := dialDevNode(ctx)
+	if err != nil {
+		return AccountState{}, err
+	}
+	defer node.Close()
+
+	if err := node.setState(ctx, "setCode", address, hexutil.Bytes(code)); err != nil {
+		return AccountState{}, err
+	}
+	nodeChainChanged(node.address)
+	return node.accountState(ctx, address)
+}
+
+func SetStorageAt(ctx context.Context, req SetStorageAtRequest) (AccountState, error) {
+	return setStorageAt(ctx, req)
+}
+
+func setStorageAt(ctx context.Context, req SetStorageAtRequest) (AccountState, error) {
+	address, err := parseDevNodeAddress(req.Address)
+	if err != nil {
+		return AccountState{}, err
+	}
+	slot, err := parseWei("slot", req.Slot)
+	if err != nil {
+		return AccountState{}, err
+	}
+	if slot == nil {
+		return AccountState{}, fmt.Errorf("slot is required")
+	}
+	value, err := parseWei("storage value", req.Value)
+	if err != nil {
+		return AccountState{}, err
+	}
+	if value == nil || value.BitLen() > 256 {
+		return AccountState{}, fmt.Errorf("invalid storage value %q", req.Value)
+	}
+
+	node, err := dialDevNode(ctx)
+	if err != nil {
+		return AccountState{}, err
+	}
+	defer node.Close()
+
+	// Hardhat requires the slot as a quantity without leading zeros and the value as a full word
+	if err := node.setState(ctx, "setStorageAt", address, (*hexutil.Big)(slot), common.BigToHash(value)); err != nil {
+		return AccountState{}, err
+	}
+	return node.accountState(ctx, address)
+}
+
+func SetNonce(ctx context.Context, req SetNonceRequest) (AccountState, error) {
+	return setNonce(ctx, req)
+}
+
+func setNonce(ctx context.Context, req SetNonceRequest) (AccountState, error) {
+	address, err := parseDevNodeAddress(req.Address)
+	if err != nil {
+		return AccountState{}, err
+	}
+
+	node, err := dialDevNode(ctx)
+	if err != nil {
+		return AccountState{}, err
+	}
+	defer node.Close()
+
+	if err := node.setState(ctx, "setNonce", address, hexutil.Uint64(req.Nonce)); err != nil {
+		return AccountState{}, err
+	}
+	return node.accountState(ctx, address)
+}
+
+func ImpersonateAccount(ctx context.Context, req ImpersonateAccountRequest) (AccountState, error) {
+	return impersonateAccount(ctx, req, true)
+}
+
+func StopImpersonatingAccount(ctx context.Context, req ImpersonateAccountRequest) (AccountState, error) {
+	return impersonateAccount(ctx, req, false)
+}
+
+func impersonateAccount(ctx context.Context, req ImpersonateAccountRequest, enabled bool) (AccountState, error) {
+	address, err := parseDevNodeAddress(req.Address)
+	if err != nil {
+		return AccountState{}, err
+	}
+
+	node, err := dialDevNode(ctx)
+	if err != nil {
+		return AccountState{}, err
+	}
+	defer node.Close()
+
+	if enabled {
+		err = node.setState(ctx, "impersonateAccount", address)
+	} else {
+		err = node.setState(ctx, "stopImpersonatingAccount", address)
+	}
+	if err != nil {
+		return AccountState{}, err
+	}
+	impersonatedAccounts.set(node.address, address, enabled)
+
+	return node.accountState(ctx, address)
+}
+
+// impersonate lets the node send transactions from the address. The returned function stops the
+// impersonation unless the account was impersonated through the API before.
+func (n *devNode) impersonate(ctx context.Context, address common.Address) (func(), error) {
+	if impersonatedAccounts.has(n.address, address) {
+		return func() {}, nil
+	}
+	if err := n.setState(ctx, "impersonateAccount", address); err != nil {
+		return nil, err
+	}
+	return func() {
+		if err := n.setState(ctx, "stopImpersonatingAccount", address); err != nil {
+			slog.WarnContext(ctx, "Failed to stop impersonating account", slog.Any("address", address.Hex()), slog.Any("err", err))
+		}
+	}, nil
+}
+
+// impersonatedSigner returns a signer sending from the impersonated address, cleanup has to be called after sending.
+func impersonatedSigner(ctx context.Context, addressStr string) (*signer, func(), error) {
+	address, err := parseDevNodeAddress(addressStr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	node, err := dialDevNode(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	stop, err := node.impersonate(ctx, address)
+	if err != nil {
+		node.Close()
+		return nil, nil, err
+	}
+
+	cleanup := func() {
+		stop()
+		node.Close()
+	}
+	return &signer{
+		name:    "impersonated",
+		kind:    SignerTypeImpersonated,
+		address: address,
+	}, cleanup, nil
+}
+
+func (s *impersonationStore) has(nodeAddress string, address common.Address) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.accounts[nodeAddress][address]
+	return ok
+}
+
+func (s *impersonationStore) set(nodeAddress string, address common.Address, enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !enabled {
+		delete(s.accounts[nodeAddress], address)
+		return
+	}
+	if s.accounts[nodeAddress] == nil {
+		s.accounts[nodeAddress] = make(map[common.Address]struct{})
+	}
+	s.accounts[nodeAddress][address] = struct{}{}
+}
+
+func ensure0xPrefix(str string) string {
+	if has0xPrefix(str) {
+		return str
+	}
+	return "0x" + str
+}
@@ -0,0 +1,52 @@
+package communicator
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestDevNodeStateMethod(t *testing.T) {
+	tests := []struct {
+		dialect  string
+		name     string
+		expected string
+	}{
+		{dialect: DevNodeDialectHardhat, name: "setBalance", expected: "hardhat_setBalance"},
+		{dialect: DevNodeDialectAnvil, name: "impersonateAccount", expected: "anvil_impersonateAccount"},
+		{dialect: DevNodeDialectGanache, name: "setStorageAt", expected: "evm_setAccountStorageAt"},
+	}
+	for _, test := range tests {
+		node := &devNode{dialect: test.dialect}
+		method, err := node.stateMethod(test.name)
+		if err != nil {
+			t.Errorf("Expected no error for %s %s, got %v", test.dialect, test.name, err)
+		}
+		if method != test.expected {
+			t.Errorf("Expected %s, got %s", test.expected, method)
+		}
+	}
+
+	node := &devNode{dialect: DevNodeDialectGanache}
+	if _, err := node.stateMethod("impersonateAccount"); err == nil {
+		t.Errorf("Expected error for impersonation on Ganache")
+	}
+}
+
+func TestImpersonationStore(t *testing.T) {
+	store := &impersonationStore{accounts: make(map[string]map[common.Address]struct{})}
+	node := "http://localhost:8545"
+	address := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+
+	if store.has(node, address) {
+		t.Errorf("Expected account not to be impersonated")
+	}
+	store.set(node, address, true)
+	if !store.has(node, address) || store.has("http://other:8545", address) {
+		t.Errorf("Expected account to be impersonated on the node only")
+	}
+	store.set(node, address, false)
+	if store.has(node, address) {
+		t.Errorf("Expected impersonation to be stopped")
+	}
+}
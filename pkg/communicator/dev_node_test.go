@@ -0,0 +1,65 @@
+package communicator
+
+import (
+	"testing"
+)
+
+func TestDetectDevNodeDialect(t *testing.T) {
+	tests := map[string]string{
+		"HardhatNetwork/2.22.3/@ethereumjs/vm/7.0.0": DevNodeDialectHardhat,
+		"anvil/v0.2.0": DevNodeDialectAnvil,
+		"Ganache/v7.9.1/EthereumJS TestRPC/v7.9.1/ethereum-js": DevNodeDialectGanache,
+		"Geth/v1.15.11-stable/linux-amd64/go1.23.0":            "",
+	}
+	for clientVersion, expected := range tests {
+		if got := detectDevNodeDialect(clientVersion); got != expected {
+			t.Errorf("Expected %q for %s, got %q", expected, clientVersion, got)
+		}
+	}
+}
+
+func TestSnapshotStore(t *testing.T) {
+	store := &snapshotStore{genesis: make(map[string]string), snapshots: make(map[string][]Snapshot)}
+	node := "http://localhost:8545"
+	store.setGenesis(node, "0xaa")
+	store.add(node, Snapshot{Name: "deployed", ID: "0x1"})
+	store.add(node, Snapshot{Name: "0x2", ID: "0x2"})
+	store.add(node, Snapshot{Name: "funded", ID: "0x3"})
+	store.add("http://other:8545", Snapshot{Name: "deployed", ID: "0x1"})
+
+	snapshot, ok := store.find(node, "funded")
+	if !ok || snapshot.ID != "0x3" {
+		t.Errorf("Expected snapshot by name, got %v", snapshot)
+	}
+	if snapshot, ok := store.find(node, "0x1"); !ok || snapshot.Name != "deployed" {
+		t.Errorf("Expected snapshot by ID, got %v", snapshot)
+	}
+
+	// Reverting invalidates the snapshot and the later ones
+	removed := store.removeFrom(node, "0x2")
+	if len(removed) != 2 || removed[0].ID != "0x2" || removed[1].ID != "0x3" {
+		t.Errorf("Expected 0x2 and 0x3 to be removed, got %v", removed)
+	}
+	if snapshots := store.list(node); len(snapshots) != 1 || snapshots[0].Name != "deployed" {
+		t.Errorf("Expected only the first snapshot to remain, got %v", snapshots)
+	}
+	if snapshots := store.list("http://other:8545"); len(snapshots) != 1 {
+		t.Errorf("Expected snapshots of other nodes to be kept, got %v", snapshots)
+	}
+
+	// Snapshots taken after a revert don't overwrite the removed ones
+	store.add(node, Snapshot{Name: "again", ID: "0x4"})
+	if len(removed) != 2 || removed[0].ID != "0x2" {
+		t.Errorf("Expected removed snapshots to be unchanged, got %v", removed)
+	}
+
+	// A restarted node reuses the snapshot IDs, the snapshots of the old chain are dropped
+	store.setGenesis(node, "0xaa")
+	if snapshots := store.list(node); len(snapshots) != 2 {
+		t.Errorf("Expected the snapshots to be kept on the same chain, got %v", snapshots)
+	}
+	store.setGenesis(node, "0xbb")
+	if _, ok := store.find(node, "0x1"); ok {
+		t.Errorf("Expected the snapshots of the old chain to be dropped")
+	}
+}
@@ -5,22 +5,54 @@ import (
 	"encoding/hex"
 	"fmt"
 	"log/slog"
+	"strings"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
 type ETHCallRequest struct {
-	Method          string   `json:"method"`
-	ContractAddress string   `json:"contract_address"`
-	ContractABI     string   `json:"contract_abi"`
-	Input           []string `json:"input"`
+	Method          string    `json:"method"`
+	ContractAddress string    `json:"contract_address"`
+	ContractABI     string    `json:"contract_abi"` // Registered ABI of the contract is used if empty
+	Input           Arguments `json:"input"`
+
+	// Block number, tag (latest, pending, earliest, safe, finalized) or block hash, latest if empty
+	Block string `json:"block"`
+	From  string `json:"from"`
+	Value string `json:"value"` // wei, decimal or 0x prefixed hex
+	Gas   uint64 `json:"gas"`
+
+	// Account state replaced for the call, keyed by address
+	StateOverrides map[string]StateOverride `json:"state_overrides"`
+}
+
+type StateOverride struct {
+	Balance string  `json:"balance"` // wei, decimal or 0x prefixed hex
+	Nonce   *uint64 `json:"nonce"`
+	Code    string  `json:"code"`
+
+	// Storage slot -> value, State replaces the whole storage while StateDiff only the given slots.
+	// Empty maps are ignored.
+	State     map[string]string `json:"state"`
+	StateDiff map[string]string `json:"state_diff"`
+}
+
+// overrideAccount is the JSON-RPC form of StateOverride.
+type overrideAccount struct {
+	Balance   *hexutil.Big                `json:"balance,omitempty"`
+	Nonce     *hexutil.Uint64             `json:"nonce,omitempty"`
+	Code      *hexutil.Bytes              `json:"code,omitempty"`
+	State     map[common.Hash]common.Hash `json:"state,omitempty"`
+	StateDiff map[common.Hash]common.Hash `json:"stateDiff,omitempty"`
 }
 
 type ETHCallResponse struct {
 	RawResponse string                 `json:"raw_response"`
 	Decoded     map[string]interface{} `json:"decoded"`
+	Revert      *RevertReason          `json:"revert,omitempty"`
 }
 
 func ETHCall(ctx context.Context, req ETHCallRequest) (ETHCallResponse, error) {
@@ -34,18 +66,52 @@ func ethCall(ctx context.Context, req ETHCallRequest) (ETHCallResponse, error) {
 		return ETHCallResponse{}, err
 	}
 
-	callData, method, err := getCallData(ctx, req.ContractABI, req.Method, req.Input)
+	contractAddress := common.HexToAddress(req.ContractAddress)
+	contractABI, err := resolveContractABI(ctx, req.ContractABI, contractAddress)
+	if err != nil {
+		return ETHCallResponse{}, err
+	}
+
+	callData, method, err := getCallData(ctx, contractABI, req.Method, req.Input)
 	if err != nil {
 		return ETHCallResponse{}, fmt.Errorf("failed to get call data: %v", err)
 	}
 
-	slog.InfoContext(ctx, "Calling contract", slog.Any("contract_address", req.ContractAddress))
-	contractAddress := common.HexToAddress(req.ContractAddress)
-	result, err := client.CallContract(ctx, ethereum.CallMsg{
+	block, err := parseBlockParameter(req.Block)
+	if err != nil {
+		return ETHCallResponse{}, err
+	}
+	overrides, err := parseStateOverrides(req.StateOverrides)
+	if err != nil {
+		return ETHCallResponse{}, err
+	}
+	msg := ethereum.CallMsg{
 		To:   &contractAddress,
+		Gas:  req.Gas,
 		Data: callData,
-	}, nil)
+	}
+	if req.From != "" {
+		if !common.IsHexAddress(req.From) {
+			return ETHCallResponse{}, fmt.Errorf("invalid from address %q", req.From)
+		}
+		msg.From = common.HexToAddress(req.From)
+	}
+	if msg.Value, err = parseWei("value", req.Value); err != nil {
+		return ETHCallResponse{}, err
+	}
+
+	slog.InfoContext(ctx, "Calling contract", slog.Any("contract_address", req.ContractAddress), slog.Any("block", req.Block))
+	result, err := callContract(ctx, client, msg, block, overrides)
 	if err != nil {
+		// Reverted calls are returned with the decoded reason instead of the raw JSON-RPC error
+		if revertData, ok := revertDataFromError(err); ok {
+			slog.InfoContext(ctx, "Contract call reverted", slog.Any("method", req.Method), slog.Any("err", err))
+			revert := decodeRevert(&contractABI, revertData)
+			return ETHCallResponse{
+				RawResponse: hex.EncodeToString(revertData),
+				Revert:      &revert,
+			}, nil
+		}
 		slog.ErrorContext(ctx, "Failed to call contract", slog.Any("method", req.Method), slog.Any("err", err))
 		return ETHCallResponse{}, err
 	}
@@ -65,3 +131,118 @@ func ethCall(ctx context.Context, req ETHCallRequest) (ETHCallResponse, error) {
 		Decoded:     decoded,
 	}, nil
 }
+
+// callContract executes eth_call at the block with the state overrides, which are omitted if empty as
+// not every node accepts the third parameter.
+func callContract(ctx context.Context, client *ethclient.Client, msg ethereum.CallMsg, block interface{}, overrides map[common.Address]overrideAccount) ([]byte, error) {
+	arg := map[string]interface{}{
+		"to":    msg.To,
+		"input": hexutil.Bytes(msg.Data),
+	}
+	if msg.From != (common.Address{}) {
+		arg["from"] = msg.From
+	}
+	if msg.Gas > 0 {
+		arg["gas"] = hexutil.Uint64(msg.Gas)
+	}
+	if msg.Value != nil {
+		arg["value"] = (*hexutil.Big)(msg.Value)
+	}
+
+	params := []interface{}{arg, block}
+	if len(overrides) > 0 {
+		params = append(params, overrides)
+	}
+
+	var result hexutil.Bytes
+	if err := client.Client().CallContext(ctx, &result, "eth_call", params...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// parseBlockParameter converts a block number, tag or hash into the block parameter of the JSON-RPC methods.
+func parseBlockParameter(str string) (interface{}, error) {
+	str = strings.TrimSpace(str)
+	switch strings.ToLower(str) {
+	case "":
+		return "latest", nil
+	case "latest", "pending", "earliest", "safe", "finalized":
+		return strings.ToLower(str), nil
+	}
+
+	// Block hashes are passed as EIP-1898 objects
+	if has0xPrefix(str) && len(str) == 2+2*common.HashLength {
+		hash, err := hexutil.Decode(str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block hash %q", str)
+		}
+		return map[string]interface{}{
+			"blockHash": common.BytesToHash(hash),
+		}, nil
+	}
+
+	number, err := parseBigInt(str)
+	if err != nil || number.Sign() < 0 || !number.IsUint64() {
+		return nil, fmt.Errorf("invalid block %q", str)
+	}
+	return hexutil.Uint64(number.Uint64()), nil
+}
+
+func parseStateOverrides(overrides map[string]StateOverride) (map[common.Address]overrideAccount, error) {
+	parsed := make(map[common.Address]overrideAccount, len(overrides))
+	for address, override := range overrides {
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid state override address %q", address)
+		}
+		if len(override.State) > 0 && len(override.StateDiff) > 0 {
+			return nil, fmt.Errorf("state override of %s has both state and state_diff", address)
+		}
+
+		var account overrideAccount
+		balance, err := parseWei("balance", override.Balance)
+		if err != nil {
+			return nil, fmt.Errorf("state override of %s: %v", address, err)
+		}
+		account.Balance = (*hexutil.Big)(balance)
+		if override.Nonce != nil {
+			nonce := hexutil.Uint64(*override.Nonce)
+			account.Nonce = &nonce
+		}
+		if override.Code != "" {
+			code, err := hexutil.Decode(ensure0xPrefix(override.Code))
+			if err != nil {
+				return nil, fmt.Errorf("state override of %s: invalid code: %v", address, err)
+			}
+			account.Code = (*hexutil.Bytes)(&code)
+		}
+		if account.State, err = parseStorageOverride(override.State); err != nil {
+			return nil, fmt.Errorf("state override of %s: %v", address, err)
+		}
+		if account.StateDiff, err = parseStorageOverride(override.StateDiff); err != nil {
+			return nil, fmt.Errorf("state override of %s: %v", address, err)
+		}
+
+		parsed[common.HexToAddress(address)] = account
+	}
+	return parsed, nil
+}
+
+func parseStorageOverride(storage map[string]string) (map[common.Hash]common.Hash, error) {
+	if len(storage) == 0 {
+		return nil, nil
+	}
+	parsed := make(map[common.Hash]common.Hash, len(storage))
+	for slotStr, valueStr := range storage {
+		slot, err := parseWei("storage slot", slotStr)
+		if err != nil || slot == nil || slot.BitLen() > 256 {
+			return nil, fmt.Errorf("invalid storage slot %q", slotStr)
+		}
+		value, err := parseWei("storage value", valueStr)
+		if err != nil || value == nil || value.BitLen() > 256 {
+			return nil, fmt.Errorf("invalid storage value %q", valueStr)
+		}
+		parsed[common.BigToHash(slot)] = common.BigToHash(value)
+	}
+	return parsed, nil
+}
@@ -2,6 +2,7 @@ package communicator
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 )
 
@@ -21,3 +22,65 @@ func TestETHCall(t *testing.T) {
 	}
 	t.Logf("Response: %v", resp)
 }
+
+func TestParseBlockParameter(t *testing.T) {
+	tests := map[string]string{
+		"":          `"latest"`,
+		"Pending":   `"pending"`,
+		"finalized": `"finalized"`,
+		"120":       `"0x78"`,
+		"0x78":      `"0x78"`,
+		"0x88e96d4537bea4d9c05d12549907b32561d3bf31f45aae734cdc119f13406cb6": `{"blockHash":"0x88e96d4537bea4d9c05d12549907b32561d3bf31f45aae734cdc119f13406cb6"}`,
+	}
+	for input, expected := range tests {
+		block, err := parseBlockParameter(input)
+		if err != nil {
+			t.Errorf("Expected no error for %q, got %v", input, err)
+			continue
+		}
+		data, err := json.Marshal(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != expected {
+			t.Errorf("Expected %s for %q, got %s", expected, input, data)
+		}
+	}
+
+	if _, err := parseBlockParameter("-1"); err == nil {
+		t.Errorf("Expected error for negative block number")
+	}
+}
+
+func TestParseStateOverrides(t *testing.T) {
+	nonce := uint64(5)
+	overrides, err := parseStateOverrides(map[string]StateOverride{
+		"0x9491A3757A98e53BE0d1c14834a6e2Da0B4Dc527": {
+			Balance:   "100000000000000000000",
+			Nonce:     &nonce,
+			Code:      "6000",
+			StateDiff: map[string]string{"0": "0x01"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	data, err := json.Marshal(overrides)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"0x9491a3757a98e53be0d1c14834a6e2da0b4dc527":{"balance":"0x56bc75e2d63100000","nonce":"0x5","code":"0x6000","stateDiff":{"0x0000000000000000000000000000000000000000000000000000000000000000":"0x0000000000000000000000000000000000000000000000000000000000000001"}}}`
+	if string(data) != expected {
+		t.Errorf("Expected %s, got %s", expected, data)
+	}
+
+	_, err = parseStateOverrides(map[string]StateOverride{
+		"0x9491A3757A98e53BE0d1c14834a6e2Da0B4Dc527": {
+			State:     map[string]string{"0": "1"},
+			StateDiff: map[string]string{"1": "1"},
+		},
+	})
+	if err == nil {
+		t.Errorf("Expected error for state and state_diff together")
+	}
+}
@@ -0,0 +1,268 @@
+package communicator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type GetGasProfileRequest struct {
+	Hash string `json:"hash"`
+
+	// ABI used for every callee which has no address specific ABI
+	ContractABI string `json:"contract_abi"`
+
+	// ABIs keyed by contract address
+	ContractABIs map[string]string `json:"contract_abis"`
+
+	// Compiler artifacts keyed by contract address, used to profile by source line. Artifacts of the
+	// contracts missing here are taken from the registries.
+	Artifacts map[string]SourceArtifact `json:"artifacts"`
+}
+
+type GetGasProfileResponse struct {
+	GasUsed uint64 `json:"gas_used"`
+
+	// Gas spent by the executed opcodes, the rest is intrinsic gas minus refunds
+	ExecutionGas uint64 `json:"execution_gas"`
+
+	Opcodes []OpcodeGas     `json:"opcodes"`
+	Calls   GasProfileFrame `json:"calls"`
+	Lines   []SourceLineGas `json:"lines"`
+
+	// Flamegraph compatible folded stacks of the call frames weighted by their own gas
+	FoldedStacks string `json:"folded_stacks"`
+}
+
+type OpcodeGas struct {
+	Opcode string `json:"opcode"`
+	Count  uint64 `json:"count"`
+	Gas    uint64 `json:"gas"`
+}
+
+type GasProfileFrame struct {
+	Type         string `json:"type"`
+	Address      string `json:"address"`
+	FunctionName string `json:"function_name,omitempty"`
+	Signature    string `json:"signature,omitempty"`
+
+	// Gas spent in the frame including its calls
+	GasUsed uint64 `json:"gas_used"`
+
+	// Gas spent by the opcodes of the frame itself
+	SelfGas uint64 `json:"self_gas"`
+
+	Calls []GasProfileFrame `json:"calls"`
+}
+
+type SourceLineGas struct {
+	File  string `json:"file"`
+	Line  int    `json:"line"`
+	Count uint64 `json:"count"`
+	Gas   uint64 `json:"gas"`
+}
+
+func GetGasProfile(ctx context.Context, req GetGasProfileRequest) (GetGasProfileResponse, error) {
+	return getGasProfile(ctx, req)
+}
+
+func getGasProfile(ctx context.Context, req GetGasProfileRequest) (GetGasProfileResponse, error) {
+	abis, err := parseContractABIs(ctx, req.ContractABI, req.ContractABIs)
+	if err != nil {
+		return GetGasProfileResponse{}, err
+	}
+	mappers, err := parseSourceArtifacts(ctx, req.Artifacts)
+	if err != nil {
+		return GetGasProfileResponse{}, err
+	}
+
+	client, err := rpc.DialContext(ctx, GetNodeAddress(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to connect to Ethereum client", slog.Any("err", err))
+		return GetGasProfileResponse{}, err
+	}
+	defer client.Close()
+
+	result, callFrame, err := traceStructLogs(ctx, client, common.HexToHash(req.Hash), map[string]interface{}{
+		"disableStack":   true,
+		"disableStorage": true,
+	})
+	if err != nil {
+		return GetGasProfileResponse{}, err
+	}
+	addRegisteredSourceMappers(ctx, mappers, callFrame)
+
+	return profileGas(ctx, abis, mappers, result, callFrame), nil
+}
+
+func parseSourceArtifacts(ctx context.Context, artifacts map[string]SourceArtifact) (map[common.Address]*sourceMapper, error) {
+	mappers := make(map[common.Address]*sourceMapper)
+	for address, artifact := range artifacts {
+		mapper, err := newSourceMapper(artifact)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to parse source artifact", slog.Any("address", address), slog.Any("err", err))
+			return nil, fmt.Errorf("failed to parse artifact of %s: %v", address, err)
+		}
+		mappers[common.HexToAddress(address)] = mapper
+	}
+	return mappers, nil
+}
+
+// addRegisteredSourceMappers adds the source mappers of the called contracts which have no artifact in
+// the request.
+func addRegisteredSourceMappers(ctx context.Context, mappers map[common.Address]*sourceMapper, frame callTracerFrame) {
+	if frame.To != nil && !isCreateOp(frame.Type) {
+		if _, ok := mappers[*frame.To]; !ok {
+			if artifact := registeredSourceArtifact(ctx, *frame.To); artifact != nil {
+				mapper, err := newSourceMapper(*artifact)
+				if err != nil {
+					slog.InfoContext(ctx, "Failed to parse registered source artifact", slog.Any("address", frame.To.Hex()), slog.Any("err", err))
+				} else {
+					mappers[*frame.To] = mapper
+				}
+			}
+		}
+	}
+	for _, call := range frame.Calls {
+		addRegisteredSourceMappers(ctx, mappers, call)
+	}
+}
+
+func profileGas(ctx context.Context, abis contractABIs, mappers map[common.Address]*sourceMapper, result structLogResult, callFrame callTracerFrame) GetGasProfileResponse {
+	costs := structLogGasCosts(result.StructLogs)
+
+	opcodes := make(map[string]*OpcodeGas)
+	lines := make(map[string]*SourceLineGas)
+	selfGas := make(map[*structLogFrame]uint64)
+	var executionGas uint64
+
+	root := walkStructLogs(result.StructLogs, &callFrame, func(index int, frame *structLogFrame) {
+		step := result.StructLogs[index]
+		cost := costs[index]
+		executionGas += cost
+		selfGas[frame] += cost
+
+		opcode, ok := opcodes[step.Op]
+		if !ok {
+			opcode = &OpcodeGas{Opcode: step.Op}
+			opcodes[step.Op] = opcode
+		}
+		opcode.Count++
+		opcode.Gas += cost
+
+		address, ok := frame.codeAddress()
+		if !ok {
+			return
+		}
+		mapper, ok := mappers[address]
+		if !ok {
+			return
+		}
+		location, ok := mapper.lookup(step.Pc)
+		if !ok {
+			return
+		}
+		key := fmt.Sprintf("%s:%d", location.File, location.Line)
+		line, ok := lines[key]
+		if !ok {
+			line = &SourceLineGas{File: location.File, Line: location.Line}
+			lines[key] = line
+		}
+		line.Count++
+		line.Gas += cost
+	})
+
+	response := GetGasProfileResponse{
+		GasUsed:      result.Gas,
+		ExecutionGas: executionGas,
+		Opcodes:      make([]OpcodeGas, 0, len(opcodes)),
+		Lines:        make([]SourceLineGas, 0, len(lines)),
+	}
+	for _, opcode := range opcodes {
+		response.Opcodes = append(response.Opcodes, *opcode)
+	}
+	sort.Slice(response.Opcodes, func(i, j int) bool {
+		if response.Opcodes[i].Gas != response.Opcodes[j].Gas {
+			return response.Opcodes[i].Gas > response.Opcodes[j].Gas
+		}
+		return response.Opcodes[i].Opcode < response.Opcodes[j].Opcode
+	})
+	for _, line := range lines {
+		response.Lines = append(response.Lines, *line)
+	}
+	sort.Slice(response.Lines, func(i, j int) bool {
+		if response.Lines[i].Gas != response.Lines[j].Gas {
+			return response.Lines[i].Gas > response.Lines[j].Gas
+		}
+		if response.Lines[i].File != response.Lines[j].File {
+			return response.Lines[i].File < response.Lines[j].File
+		}
+		return response.Lines[i].Line < response.Lines[j].Line
+	})
+
+	response.Calls = profileFrame(ctx, abis, root, selfGas)
+
+	var folded strings.Builder
+	writeFoldedStacks(&folded, "", response.Calls)
+	response.FoldedStacks = folded.String()
+
+	return response
+}
+
+func profileFrame(ctx context.Context, abis contractABIs, frame *structLogFrame, selfGas map[*structLogFrame]uint64) GasProfileFrame {
+	profile := GasProfileFrame{
+		Type:    strings.ToUpper(frame.call.Type),
+		Address: safeHexAddress(frame.call.To),
+		SelfGas: selfGas[frame],
+		Calls:   make([]GasProfileFrame, 0, len(frame.children)),
+	}
+	profile.GasUsed = profile.SelfGas
+
+	if address, ok := frame.codeAddress(); ok && len(frame.call.Input) >= 4 {
+		profile.Signature = hexutil.Encode(frame.call.Input[:4])
+		if contractABI := abis.get(ctx, address); contractABI != nil {
+			if method, err := contractABI.MethodById(frame.call.Input[:4]); err == nil {
+				profile.FunctionName = method.Name
+				profile.Signature = method.Sig
+			}
+		}
+	}
+
+	for _, child := range frame.children {
+		childProfile := profileFrame(ctx, abis, child, selfGas)
+		profile.GasUsed += childProfile.GasUsed
+		profile.Calls = append(profile.Calls, childProfile)
+	}
+
+	return profile
+}
+
+// writeFoldedStacks writes a "frame;frame;frame gas" line for every frame which spent gas itself.
+func writeFoldedStacks(builder *strings.Builder, prefix string, frame GasProfileFrame) {
+	name := frame.Type
+	if frame.Address != "" {
+		name += ":" + frame.Address
+	}
+	if frame.Signature != "" {
+		name += ":" + frame.Signature
+	}
+	// Spaces and semicolons are the separators of the format
+	name = strings.NewReplacer(" ", "_", ";", "_").Replace(name)
+
+	stack := name
+	if prefix != "" {
+		stack = prefix + ";" + name
+	}
+	if frame.SelfGas > 0 {
+		fmt.Fprintf(builder, "%s %d\n", stack, frame.SelfGas)
+	}
+	for _, call := range frame.Calls {
+		writeFoldedStacks(builder, stack, call)
+	}
+}
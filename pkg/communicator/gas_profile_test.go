@@ -0,0 +1,113 @@
+package communicator
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestProfileGas(t *testing.T) {
+	// Struct log of a contract calling transfer on a token, the CALL gas cost contains the forwarded gas
+	rawResult := `{
+		"gas": 21102,
+		"failed": false,
+		"returnValue": "",
+		"structLogs": [
+			{"pc": 0, "op": "PUSH1", "gas": 1000, "gasCost": 3, "depth": 1},
+			{"pc": 2, "op": "CALL", "gas": 997, "gasCost": 500, "depth": 1},
+			{"pc": 0, "op": "PUSH1", "gas": 400, "gasCost": 3, "depth": 2},
+			{"pc": 2, "op": "STOP", "gas": 397, "gasCost": 0, "depth": 2},
+			{"pc": 3, "op": "POP", "gas": 900, "gasCost": 2, "depth": 1},
+			{"pc": 4, "op": "STOP", "gas": 898, "gasCost": 0, "depth": 1}
+		]
+	}`
+	rawTrace := `{
+		"type": "CALL",
+		"from": "0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266",
+		"to": "0xe7f1725e7734ce288f8367e1bb143e90bb3f0512",
+		"input": "0x12345678",
+		"calls": [{
+			"type": "CALL",
+			"from": "0xe7f1725e7734ce288f8367e1bb143e90bb3f0512",
+			"to": "0x5fbdb2315678afecb367f032d93f642f64180aa3",
+			"input": "0xa9059cbb0000000000000000000000002857d75d6f42052ee415396ef1989c96b0768c7c00000000000000000000000000000000000000000000000000000000447bd088"
+		}]
+	}`
+	var result structLogResult
+	if err := json.Unmarshal([]byte(rawResult), &result); err != nil {
+		t.Fatalf("Failed to unmarshal struct logs: %v", err)
+	}
+	var frame callTracerFrame
+	if err := json.Unmarshal([]byte(rawTrace), &frame); err != nil {
+		t.Fatalf("Failed to unmarshal trace: %v", err)
+	}
+	abis, err := parseContractABIs(context.Background(), "", map[string]string{
+		"0x5FbDB2315678afecb367f032d93F642f64180aa3": contractABI,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	mappers, err := parseSourceArtifacts(context.Background(), map[string]SourceArtifact{
+		"0x5FbDB2315678afecb367f032d93F642f64180aa3": {
+			DeployedBytecode:  "0x600100",
+			DeployedSourceMap: "0:4:0;9:3",
+			Sources:           []SourceFile{{ID: 0, Name: "Token.sol", Content: "line one\nline two\n"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	profile := profileGas(context.Background(), abis, mappers, result, frame)
+	if profile.ExecutionGas != 102 {
+		t.Errorf("Expected execution gas 102, got %d", profile.ExecutionGas)
+	}
+
+	opcodes := make(map[string]OpcodeGas)
+	for _, opcode := range profile.Opcodes {
+		opcodes[opcode.Opcode] = opcode
+	}
+	if opcodes["CALL"].Gas != 94 {
+		t.Errorf("Expected CALL to cost 94 without the callee, got %d", opcodes["CALL"].Gas)
+	}
+	if opcodes["PUSH1"].Count != 2 || opcodes["PUSH1"].Gas != 6 {
+		t.Errorf("Unexpected PUSH1 profile %v", opcodes["PUSH1"])
+	}
+
+	if profile.Calls.GasUsed != 102 || profile.Calls.SelfGas != 99 || len(profile.Calls.Calls) != 1 {
+		t.Fatalf("Unexpected root frame %v", profile.Calls)
+	}
+	if call := profile.Calls.Calls[0]; call.FunctionName != "transfer" || call.GasUsed != 3 {
+		t.Errorf("Unexpected call frame %v", call)
+	}
+
+	if len(profile.Lines) != 2 || profile.Lines[0].File != "Token.sol" || profile.Lines[0].Line != 1 || profile.Lines[0].Gas != 3 {
+		t.Errorf("Unexpected line profile %v", profile.Lines)
+	}
+
+	expectedFolded := "CALL:0xe7f1725E7734CE288F8367e1Bb143E90bb3F0512:0x12345678 99\n" +
+		"CALL:0xe7f1725E7734CE288F8367e1Bb143E90bb3F0512:0x12345678;CALL:0x5FbDB2315678afecb367f032d93F642f64180aa3:transfer(address,uint256) 3\n"
+	if profile.FoldedStacks != expectedFolded {
+		t.Errorf("Unexpected folded stacks:\n%s", profile.FoldedStacks)
+	}
+}
+
+func TestSourceMapper(t *testing.T) {
+	mapper, err := newSourceMapper(SourceArtifact{
+		DeployedBytecode:  "0x6001600201",
+		DeployedSourceMap: "0:4:0:-;9:3;:::i",
+		Sources:           []SourceFile{{ID: 0, Name: "A.sol", Content: "line one\nline two\n"}},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// PUSH1 takes two bytes, the ADD at pc 4 is the third instruction
+	location, ok := mapper.lookup(4)
+	if !ok || location.Line != 2 || location.Column != 1 || location.Length != 3 || location.Jump != "i" {
+		t.Errorf("Unexpected location %v", location)
+	}
+	if _, ok := mapper.lookup(1); ok {
+		t.Errorf("Expected no location inside push data")
+	}
+}
@@ -0,0 +1,241 @@
+package communicator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+const DefaultIndexerPollInterval = 2 * time.Second
+
+var errParentMismatch = errors.New("parent hash mismatch")
+
+var (
+	registeredIndexerMu sync.RWMutex
+	registeredIndexer   *Indexer
+)
+
+// Indexer follows the chain head of a node and stores blocks, transactions, receipts and logs into the Store.
+type Indexer struct {
+	nodeAddress  string
+	store        *Store
+	pollInterval time.Duration
+
+	resetFeed event.Feed
+	resetsMu  sync.RWMutex
+	resets    []ChainResetEvent
+
+	// The stored head was on the node's chain at the last sync, readers skip the store otherwise
+	consistent atomic.Bool
+}
+
+func NewIndexer(nodeAddress string, store *Store, pollInterval time.Duration) *Indexer {
+	if pollInterval <= 0 {
+		pollInterval = DefaultIndexerPollInterval
+	}
+	return &Indexer{
+		nodeAddress:  nodeAddress,
+		store:        store,
+		pollInterval: pollInterval,
+	}
+}
+
+// SetIndexer registers the indexer, requests targeting its node are served from its store.
+func SetIndexer(indexer *Indexer) {
+	registeredIndexerMu.Lock()
+	defer registeredIndexerMu.Unlock()
+	registeredIndexer = indexer
+}
+
+func getIndexer(ctx context.Context) *Indexer {
+	registeredIndexerMu.RLock()
+	defer registeredIndexerMu.RUnlock()
+	if registeredIndexer == nil || registeredIndexer.nodeAddress != GetNodeAddress(ctx) {
+		return nil
+	}
+	return registeredIndexer
+}
+
+// Run indexes the chain until the context is cancelled.
+func (i *Indexer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(i.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := i.sync(ctx); err != nil {
+			i.consistent.Store(false)
+			slog.ErrorContext(ctx, "Failed to sync indexer", slog.Any("node_address", i.nodeAddress), slog.Any("err", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (i *Indexer) sync(ctx context.Context) error {
+	client, err := ethclient.DialContext(ctx, i.nodeAddress)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Ethereum client: %v", err)
+	}
+	defer client.Close()
+
+	if err := i.checkChain(ctx, client); err != nil {
+		return err
+	}
+
+	head, err := client.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get latest block number: %v", err)
+	}
+
+	storedHead, ok, err := i.store.Head()
+	if err != nil {
+		return fmt.Errorf("failed to get stored head: %v", err)
+	}
+	if ok {
+		commonAncestor, reorged, err := i.detectReorg(ctx, client, storedHead, head)
+		if err != nil {
+			return err
+		}
+		if reorged {
+			reason := ChainResetReasonReorg
+			if head < storedHead {
+				reason = ChainResetReasonHeadReversed
+			}
+			if err := i.rollback(ctx, reason, storedHead, head, commonAncestor); err != nil {
+				return err
+			}
+			storedHead, ok = commonAncestor, commonAncestor > 0
+		}
+	}
+	// Blocks are only appended on top of the verified head from here
+	i.consistent.Store(true)
+
+	next := uint64(1)
+	if ok {
+		next = storedHead + 1
+	}
+	for ; next <= head; next++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := i.indexBlock(ctx, client, next); err != nil {
+			if errors.Is(err, errParentMismatch) {
+				// The chain changed under us, the next sync rolls back to the common ancestor
+				slog.InfoContext(ctx, "Parent hash mismatch while indexing", slog.Any("block_number", next))
+				i.consistent.Store(false)
+				return nil
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkChain wipes the store if the node is on a different chain than the stored data,
+// which is what happens on every hardhat restart.
+func (i *Indexer) checkChain(ctx context.Context, client *ethclient.Client) error {
+	chainID, genesisHash, err := getChainInfo(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	storedChainID, storedGenesisHash, err := i.store.ChainInfo()
+	if err != nil {
+		return fmt.Errorf("failed to get stored chain info: %v", err)
+	}
+	if storedChainID == chainID && storedGenesisHash == genesisHash {
+		return nil
+	}
+
+	if storedChainID != "" || storedGenesisHash != "" {
+		storedHead, _, err := i.store.Head()
+		if err != nil {
+			return fmt.Errorf("failed to get stored head: %v", err)
+		}
+		if err := i.store.Wipe(); err != nil {
+			return fmt.Errorf("failed to wipe store: %v", err)
+		}
+		slog.InfoContext(ctx, "Chain changed, reindexing", slog.Any("chain_id", chainID), slog.Any("genesis_hash", genesisHash))
+		i.emitChainReset(ChainResetEvent{
+			Reason:    ChainResetReasonGenesisChanged,
+			OldHead:   storedHead,
+			Timestamp: time.Now().Unix(),
+		})
+	}
+	return i.store.SetChainInfo(chainID, genesisHash)
+}
+
+func (i *Indexer) indexBlock(ctx context.Context, client *ethclient.Client, number uint64) error {
+	block, err := client.BlockByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return fmt.Errorf("failed to retrieve block %d: %v", number, err)
+	}
+
+	parsedBlock, err := parseBlock(ctx, block)
+	if err != nil {
+		return fmt.Errorf("failed to parse block %d: %v", number, err)
+	}
+
+	// The new block has to extend the stored chain
+	if number > 1 {
+		parent, ok, err := i.store.Block(number - 1)
+		if err != nil {
+			return fmt.Errorf("failed to get stored block %d: %v", number-1, err)
+		}
+		if ok && parent.Header.Hash != parsedBlock.Header.ParentHash {
+			return errParentMismatch
+		}
+	}
+
+	if err := addReceipts(ctx, client, block, &parsedBlock); err != nil {
+		return err
+	}
+
+	if err := i.store.WriteBlock(parsedBlock); err != nil {
+		return fmt.Errorf("failed to store block %d: %v", number, err)
+	}
+	return nil
+}
+
+func (i *Indexer) block(ctx context.Context, number uint64) (Block, bool) {
+	block, ok, err := i.store.Block(number)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get block from store", slog.Any("block_number", number), slog.Any("err", err))
+		return Block{}, false
+	}
+	return block, ok
+}
+
+func (i *Indexer) transaction(ctx context.Context, hash string) (Transaction, bool) {
+	transaction, ok, err := i.store.Transaction(hash)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get transaction from store", slog.Any("hash", hash), slog.Any("err", err))
+		return Transaction{}, false
+	}
+	return transaction, ok
+}
+
+func getChainInfo(ctx context.Context, client *ethclient.Client) (string, string, error) {
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get chain ID: %v", err)
+	}
+	genesis, err := client.HeaderByNumber(ctx, big.NewInt(0))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get genesis block: %v", err)
+	}
+	return chainID.String(), genesis.Hash().Hex(), nil
+}
@@ -2,48 +2,26 @@ package communicator
 
 import (
 	"context"
-	"encoding/hex"
 	"fmt"
 	"log/slog"
-	"math/big"
+	"sort"
 	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 )
 
-func getCallData(ctx context.Context, contractABI, selectedMethod string, inputStr []string) ([]byte, abi.Method, error) {
-	parsedABI, err := abi.JSON(strings.NewReader(contractABI))
+func getCallData(ctx context.Context, parsedABI abi.ABI, selectedMethod string, inputStr []string) ([]byte, abi.Method, error) {
+	method, err := findMethod(parsedABI, selectedMethod)
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to parse contract ABI", slog.Any("err", err))
-		return nil, abi.Method{}, fmt.Errorf("failed to parse ABI: %v", err)
+		slog.ErrorContext(ctx, "Failed to find method", slog.Any("method", selectedMethod), slog.Any("err", err))
+		return nil, abi.Method{}, err
 	}
 
-	var method abi.Method
-	for _, m := range parsedABI.Methods {
-		if strings.Contains(m.Name, selectedMethod) {
-			method = m
-		}
-	}
-
-	var convertedArgs []interface{}
-	for i, input := range method.Inputs {
-		switch input.Type.String() {
-		case "address":
-			convertedArgs = append(convertedArgs, common.HexToAddress(inputStr[i]))
-		case "uint256":
-			val := new(big.Int)
-			val.SetString(inputStr[i], 10)
-			convertedArgs = append(convertedArgs, val)
-		case "bytes32":
-			var b32 [32]byte
-			copy(b32[:], inputStr[i])
-			convertedArgs = append(convertedArgs, b32)
-		case "string":
-			convertedArgs = append(convertedArgs, inputStr[i])
-		default:
-			slog.ErrorContext(ctx, "Unsupported input type", slog.Any("type", input.Type.String()))
-		}
+	convertedArgs, err := convertArguments(method.Inputs, inputStr)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to convert input", slog.Any("method", method.Name), slog.Any("err", err))
+		return nil, abi.Method{}, fmt.Errorf("failed to convert input: %v", err)
 	}
 
 	data, err := method.Inputs.Pack(convertedArgs...)
@@ -56,41 +34,179 @@ func getCallData(ctx context.Context, contractABI, selectedMethod string, inputS
 	return callData, method, nil
 }
 
+// findMethod looks up a method by its 4-byte selector (0x prefixed), canonical signature
+// (e.g. "transfer(address,uint256)") or name. Overloaded methods can't be selected by name,
+// except by the unique name the abi package gives them (e.g. "safeTransferFrom0").
+func findMethod(parsedABI abi.ABI, selectedMethod string) (abi.Method, error) {
+	selectedMethod = strings.TrimSpace(selectedMethod)
+
+	if has0xPrefix(selectedMethod) {
+		selector := common.FromHex(selectedMethod)
+		if len(selector) != 4 {
+			return abi.Method{}, fmt.Errorf("invalid selector %s", selectedMethod)
+		}
+		method, err := parsedABI.MethodById(selector)
+		if err != nil {
+			return abi.Method{}, fmt.Errorf("no method found for selector %s", selectedMethod)
+		}
+		return *method, nil
+	}
+
+	if strings.Contains(selectedMethod, "(") {
+		signature := strings.ReplaceAll(selectedMethod, " ", "")
+		for _, method := range parsedABI.Methods {
+			if method.Sig == signature {
+				return method, nil
+			}
+		}
+		return abi.Method{}, fmt.Errorf("no method found for signature %s", selectedMethod)
+	}
+
+	var candidates []abi.Method
+	for _, method := range parsedABI.Methods {
+		if method.RawName == selectedMethod || method.Name == selectedMethod {
+			candidates = append(candidates, method)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return abi.Method{}, fmt.Errorf("method %s not found", selectedMethod)
+	case 1:
+		return candidates[0], nil
+	}
+
+	signatures := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		signatures = append(signatures, candidate.Sig)
+	}
+	sort.Strings(signatures)
+	return abi.Method{}, fmt.Errorf("method %s is ambiguous, use one of the signatures: %s", selectedMethod, strings.Join(signatures, ", "))
+}
+
 func parseResult(ctx context.Context, method abi.Method, result []byte) (map[string]interface{}, error) {
-	outputs, err := method.Outputs.Unpack(result)
+	decoded, err := unpackToJSON(method.Outputs, result, "output")
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to unpack result", slog.Any("method", method), slog.Any("err", err))
 		return nil, fmt.Errorf("failed to unpack result: %v", err)
 	}
-	decoded := make(map[string]interface{})
-	for i, output := range method.Outputs {
-		name := output.Name
-		if name == "" {
-			name = fmt.Sprintf("output_%d", i)
+
+	return decoded, nil
+}
+
+// resolveContractABI parses the given ABI, or looks up the ABI registered for the contract if it's empty.
+func resolveContractABI(ctx context.Context, contractABI string, address common.Address) (abi.ABI, error) {
+	if contractABI != "" {
+		parsedABI, err := abi.JSON(strings.NewReader(contractABI))
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to parse contract ABI", slog.Any("err", err))
+			return abi.ABI{}, fmt.Errorf("failed to parse ABI: %v", err)
 		}
-		switch output.Type.String() {
-		case "address":
-			decoded[name] = outputs[i].(common.Address)
-		case "uint256", "uint48", "uint64", "uint32", "uint16", "uint8":
-			if val, ok := outputs[i].(*big.Int); ok {
-				decoded[name] = val.String()
-			} else {
-				decoded[name] = outputs[i]
-			}
-		case "bytes32":
-			if b32, ok := outputs[i].([32]byte); ok {
-				decoded[name] = hex.EncodeToString(b32[:])
-			} else {
-				decoded[name] = outputs[i]
-			}
-		case "string":
-			if str, ok := outputs[i].(string); ok {
-				decoded[name] = str
-			} else {
-				decoded[name] = outputs[i]
-			}
+		return parsedABI, nil
+	}
+
+	if registered := registeredContractABI(ctx, address); registered != nil {
+		return *registered, nil
+	}
+	return abi.ABI{}, fmt.Errorf("no ABI provided and none registered for %s", address.Hex())
+}
+
+// registeredContractABI returns the ABI of the contract from the registries, nil if it's unknown.
+// ABIs saved by the user take precedence over the artifacts.
+func registeredContractABI(ctx context.Context, address common.Address) *abi.ABI {
+	if registry := getContractRegistry(); registry != nil {
+		if contract, ok := registry.contract(address); ok {
+			return &contract.abi
 		}
 	}
+	if registry := getArtifactRegistry(); registry != nil {
+		if contractABI := registry.ContractABI(ctx, address); contractABI != nil {
+			return contractABI
+		}
+	}
+	return nil
+}
 
-	return decoded, nil
+// registeredStorageLayout returns the storage layout of the contract from the registries, nil if it's unknown.
+func registeredStorageLayout(ctx context.Context, address common.Address) *StorageLayout {
+	if registry := getContractRegistry(); registry != nil {
+		if contract, ok := registry.contract(address); ok && contract.StorageLayout != nil {
+			return contract.StorageLayout
+		}
+	}
+	if registry := getArtifactRegistry(); registry != nil {
+		return registry.StorageLayout(ctx, address)
+	}
+	return nil
+}
+
+// registeredSourceArtifact returns the source map and sources of the contract from the registries, nil if
+// they are unknown.
+func registeredSourceArtifact(ctx context.Context, address common.Address) *SourceArtifact {
+	if registry := getContractRegistry(); registry != nil {
+		if contract, ok := registry.contract(address); ok && contract.SourceArtifact != nil {
+			return contract.SourceArtifact
+		}
+	}
+	if registry := getArtifactRegistry(); registry != nil {
+		return registry.SourceArtifact(ctx, address)
+	}
+	return nil
+}
+
+// contractABIs resolves the ABI of a contract by its address, falling back to the default ABI of the
+// request and then to the registered ABIs.
+type contractABIs struct {
+	defaultABI *abi.ABI
+	byAddress  map[common.Address]*abi.ABI
+}
+
+func parseContractABIs(ctx context.Context, contractABI string, contractABIsByAddress map[string]string) (contractABIs, error) {
+	abis := contractABIs{
+		byAddress: make(map[common.Address]*abi.ABI),
+	}
+
+	if contractABI != "" {
+		parsedABI, err := abi.JSON(strings.NewReader(contractABI))
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to parse contract ABI", slog.Any("err", err))
+			return contractABIs{}, fmt.Errorf("failed to parse ABI: %v", err)
+		}
+		abis.defaultABI = &parsedABI
+	}
+
+	for address, addressABI := range contractABIsByAddress {
+		parsedABI, err := abi.JSON(strings.NewReader(addressABI))
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to parse contract ABI", slog.Any("address", address), slog.Any("err", err))
+			return contractABIs{}, fmt.Errorf("failed to parse ABI of %s: %v", address, err)
+		}
+		abis.byAddress[common.HexToAddress(address)] = &parsedABI
+	}
+
+	return abis, nil
+}
+
+func (c contractABIs) get(ctx context.Context, address common.Address) *abi.ABI {
+	if contractABI, ok := c.byAddress[address]; ok {
+		return contractABI
+	}
+	if c.defaultABI != nil {
+		return c.defaultABI
+	}
+	return registeredContractABI(ctx, address)
+}
+
+// registeredContractName returns the name the contract is registered with, empty if it's unknown.
+func registeredContractName(ctx context.Context, address common.Address) string {
+	if registry := getContractRegistry(); registry != nil {
+		if contract, ok := registry.contract(address); ok && contract.Name != "" {
+			return contract.Name
+		}
+	}
+	if registry := getArtifactRegistry(); registry != nil {
+		if loaded := registry.artifactAt(ctx, address); loaded != nil {
+			return loaded.name
+		}
+	}
+	return ""
 }
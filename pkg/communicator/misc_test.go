@@ -0,0 +1,49 @@
+package communicator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+const (
+	// ERC721 safeTransferFrom overloads for testing
+	overloadedContractABI = `[{"inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"tokenId","type":"uint256"}],"name":"safeTransferFrom","outputs":[],"stateMutability":"nonpayable","type":"function"},{"inputs":[{"name":"from","type":"address"},{"name":"to","type":"address"},{"name":"tokenId","type":"uint256"},{"name":"data","type":"bytes"}],"name":"safeTransferFrom","outputs":[],"stateMutability":"nonpayable","type":"function"}]`
+)
+
+func TestFindMethod(t *testing.T) {
+	erc20ABI, err := abi.JSON(strings.NewReader(contractABI))
+	if err != nil {
+		t.Fatalf("Failed to parse ABI: %v", err)
+	}
+	overloadedABI, err := abi.JSON(strings.NewReader(overloadedContractABI))
+	if err != nil {
+		t.Fatalf("Failed to parse ABI: %v", err)
+	}
+
+	// Name matching must be exact, transfer must not select transferFrom
+	method, err := findMethod(erc20ABI, "transfer")
+	if err != nil || method.Sig != "transfer(address,uint256)" {
+		t.Errorf("Expected transfer(address,uint256), got %s (%v)", method.Sig, err)
+	}
+
+	method, err = findMethod(erc20ABI, "0x23b872dd")
+	if err != nil || method.Name != "transferFrom" {
+		t.Errorf("Expected transferFrom by selector, got %s (%v)", method.Name, err)
+	}
+
+	method, err = findMethod(overloadedABI, "safeTransferFrom(address, address, uint256, bytes)")
+	if err != nil || len(method.Inputs) != 4 {
+		t.Errorf("Expected 4 argument overload by signature, got %s (%v)", method.Sig, err)
+	}
+
+	_, err = findMethod(overloadedABI, "safeTransferFrom")
+	if err == nil || !strings.Contains(err.Error(), "safeTransferFrom(address,address,uint256)") {
+		t.Errorf("Expected ambiguity error listing the candidates, got %v", err)
+	}
+
+	if _, err = findMethod(erc20ABI, "mint"); err == nil {
+		t.Errorf("Expected error for unknown method")
+	}
+}
@@ -0,0 +1,217 @@
+package communicator
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"math/big"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+const testChainID = 31337
+
+// Every test chain gets its own genesis block, like a restarted dev node
+var testChainCount atomic.Uint64
+
+// newTestNode serves the JSON-RPC services, e.g. "eth", and returns a context pointing to them.
+func newTestNode(t *testing.T, services map[string]interface{}) context.Context {
+	server := rpc.NewServer()
+	for namespace, service := range services {
+		if err := server.RegisterName(namespace, service); err != nil {
+			t.Fatalf("Failed to register %s service: %v", namespace, err)
+		}
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Stop()
+	})
+	return SetNodeAddress(context.Background(), httpServer.URL)
+}
+
+// testChain is an eth service over a fixed chain of blocks and account states.
+type testChain struct {
+	mu       sync.RWMutex
+	blocks   []*types.Block
+	balances map[common.Address]*big.Int
+	nonces   map[common.Address]uint64
+	codes    map[common.Address][]byte
+}
+
+// newTestChain builds a post-Cancun genesis block and one block per entry of txs.
+func newTestChain(t *testing.T, txs ...[]*types.Transaction) *testChain {
+	chain := &testChain{
+		balances: make(map[common.Address]*big.Int),
+		nonces:   make(map[common.Address]uint64),
+		codes:    make(map[common.Address][]byte),
+	}
+	genesisTime := 1_700_000_000 + testChainCount.Add(1)*1000
+	parent := common.Hash{}
+	for i := 0; i <= len(txs); i++ {
+		var blobGas uint64
+		header := &types.Header{
+			ParentHash:       parent,
+			Number:           big.NewInt(int64(i)),
+			GasLimit:         30_000_000,
+			Time:             genesisTime + uint64(i),
+			Difficulty:       big.NewInt(0),
+			BaseFee:          big.NewInt(1),
+			BlobGasUsed:      &blobGas,
+			ExcessBlobGas:    &blobGas,
+			ParentBeaconRoot: &common.Hash{},
+		}
+		body := types.Body{Withdrawals: types.Withdrawals{}}
+		if i > 0 {
+			body.Transactions = txs[i-1]
+		}
+		block := types.NewBlock(header, &body, nil, trie.NewStackTrie(nil))
+		chain.blocks = append(chain.blocks, block)
+		parent = block.Hash()
+	}
+	return chain
+}
+
+// newTestTransaction signs a transfer of the nonce in wei, so every transaction has a distinct hash.
+func newTestTransaction(t *testing.T, key *ecdsa.PrivateKey, nonce uint64, to common.Address) *types.Transaction {
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(testChainID)), &types.DynamicFeeTx{
+		ChainID:   big.NewInt(testChainID),
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(2),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(int64(nonce)),
+	})
+	if err != nil {
+		t.Fatalf("Failed to sign transaction: %v", err)
+	}
+	return tx
+}
+
+// setBlocks replaces the chain, e.g. to simulate a reorg or a restarted node.
+func (c *testChain) setBlocks(blocks []*types.Block) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.blocks = blocks
+}
+
+func (c *testChain) block(number rpc.BlockNumber) *types.Block {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if number < 0 {
+		return c.blocks[len(c.blocks)-1]
+	}
+	if int(number) >= len(c.blocks) {
+		return nil
+	}
+	return c.blocks[number]
+}
+
+func (c *testChain) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(testChainID))
+}
+
+func (c *testChain) BlockNumber() hexutil.Uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return hexutil.Uint64(len(c.blocks) - 1)
+}
+
+func (c *testChain) GetBalance(address common.Address, block rpc.BlockNumberOrHash) *hexutil.Big {
+	if balance, ok := c.balances[address]; ok {
+		return (*hexutil.Big)(balance)
+	}
+	return (*hexutil.Big)(big.NewInt(0))
+}
+
+func (c *testChain) GetTransactionCount(address common.Address, block rpc.BlockNumberOrHash) hexutil.Uint64 {
+	return hexutil.Uint64(c.nonces[address])
+}
+
+func (c *testChain) GetCode(address common.Address, block rpc.BlockNumberOrHash) hexutil.Bytes {
+	return c.codes[address]
+}
+
+// GetBlockByNumber returns the block in the JSON-RPC format, null for unknown blocks.
+func (c *testChain) GetBlockByNumber(number rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
+	block := c.block(number)
+	if block == nil {
+		return nil, nil
+	}
+	var fields map[string]interface{}
+	data, err := json.Marshal(block.Header())
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	transactions := make([]interface{}, 0, len(block.Transactions()))
+	for i, tx := range block.Transactions() {
+		if !fullTx {
+			transactions = append(transactions, tx.Hash())
+			continue
+		}
+		var txFields map[string]interface{}
+		data, err := json.Marshal(tx)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &txFields); err != nil {
+			return nil, err
+		}
+		from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		if err != nil {
+			return nil, err
+		}
+		txFields["from"] = from
+		txFields["blockHash"] = block.Hash()
+		txFields["blockNumber"] = (*hexutil.Big)(block.Number())
+		txFields["transactionIndex"] = hexutil.Uint64(i)
+		transactions = append(transactions, txFields)
+	}
+	fields["hash"] = block.Hash()
+	fields["transactions"] = transactions
+	fields["uncles"] = []common.Hash{}
+	fields["withdrawals"] = block.Withdrawals()
+	return fields, nil
+}
+
+// GetTransactionReceipt returns a successful receipt for every transaction of the chain.
+func (c *testChain) GetTransactionReceipt(hash common.Hash) (*types.Receipt, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, block := range c.blocks {
+		for i, tx := range block.Transactions() {
+			if tx.Hash() != hash {
+				continue
+			}
+			receipt := &types.Receipt{
+				Type:              tx.Type(),
+				Status:            types.ReceiptStatusSuccessful,
+				CumulativeGasUsed: uint64(i+1) * params.TxGas,
+				Logs:              []*types.Log{},
+				TxHash:            hash,
+				GasUsed:           params.TxGas,
+				EffectiveGasPrice: block.BaseFee(),
+				BlockHash:         block.Hash(),
+				BlockNumber:       block.Number(),
+				TransactionIndex:  uint(i),
+			}
+			receipt.Bloom = types.CreateBloom(receipt)
+			return receipt, nil
+		}
+	}
+	return nil, nil
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
 type ParseContractABIRequest struct {
@@ -20,6 +21,8 @@ type ParseContractABIResponse struct {
 
 type Method struct {
 	Name            string   `json:"name"`
+	Signature       string   `json:"signature"`
+	Selector        string   `json:"selector"`
 	StateMutability string   `json:"state_mutability"`
 	Inputs          []string `json:"inputs"`
 	Outputs         []string `json:"outputs"`
@@ -50,6 +53,8 @@ func parseContractABI(ctx context.Context, req ParseContractABIRequest) (ParseCo
 			}
 			response.Methods = append(response.Methods, Method{
 				Name:            method.Name,
+				Signature:       method.Sig,
+				Selector:        hexutil.Encode(method.ID),
 				StateMutability: method.StateMutability,
 				Inputs:          inputs,
 				Outputs:         outputs,
@@ -0,0 +1,90 @@
+package communicator
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type Receipt struct {
+	Status            string `json:"status"`
+	CumulativeGasUsed uint64 `json:"cumulative_gas_used"`
+	GasUsed           uint64 `json:"gas_used"`
+	EffectiveGasPrice string `json:"effective_gas_price"`
+	BlobGasUsed       uint64 `json:"blob_gas_used"`
+	BlobGasPrice      string `json:"blob_gas_price"`
+	ContractAddress   string `json:"contract_address"`
+	BlockHash         string `json:"block_hash"`
+	BlockNumber       string `json:"block_number"`
+	TransactionIndex  uint   `json:"transaction_index"`
+	Logs              []Log  `json:"logs"`
+}
+
+type Log struct {
+	Address          string   `json:"address"`
+	Topics           []string `json:"topics"`
+	Data             string   `json:"data"`
+	BlockNumber      uint64   `json:"block_number"`
+	BlockHash        string   `json:"block_hash"`
+	TransactionHash  string   `json:"transaction_hash"`
+	TransactionIndex uint     `json:"transaction_index"`
+	LogIndex         uint     `json:"log_index"`
+	Removed          bool     `json:"removed"`
+}
+
+func parseReceipt(receipt *types.Receipt) Receipt {
+	// Contract address is only set for contract creation transactions
+	contractAddress := ""
+	if receipt.ContractAddress != (common.Address{}) {
+		contractAddress = receipt.ContractAddress.Hex()
+	}
+
+	logs := make([]Log, 0, len(receipt.Logs))
+	for _, log := range receipt.Logs {
+		logs = append(logs, parseLog(log))
+	}
+
+	return Receipt{
+		Status:            parseReceiptStatus(receipt.Status),
+		CumulativeGasUsed: receipt.CumulativeGasUsed,
+		GasUsed:           receipt.GasUsed,
+		EffectiveGasPrice: safeBigIntToString(receipt.EffectiveGasPrice),
+		BlobGasUsed:       receipt.BlobGasUsed,
+		BlobGasPrice:      safeBigIntToString(receipt.BlobGasPrice),
+		ContractAddress:   contractAddress,
+		BlockHash:         receipt.BlockHash.Hex(),
+		BlockNumber:       safeBigIntToString(receipt.BlockNumber),
+		TransactionIndex:  receipt.TransactionIndex,
+		Logs:              logs,
+	}
+}
+
+func parseLog(log *types.Log) Log {
+	topics := make([]string, 0, len(log.Topics))
+	for _, topic := range log.Topics {
+		topics = append(topics, topic.Hex())
+	}
+
+	return Log{
+		Address:          log.Address.Hex(),
+		Topics:           topics,
+		Data:             fmt.Sprintf("0x%x", log.Data),
+		BlockNumber:      log.BlockNumber,
+		BlockHash:        log.BlockHash.Hex(),
+		TransactionHash:  log.TxHash.Hex(),
+		TransactionIndex: log.TxIndex,
+		LogIndex:         log.Index,
+		Removed:          log.Removed,
+	}
+}
+
+func parseReceiptStatus(status uint64) string {
+	switch status {
+	case types.ReceiptStatusSuccessful:
+		return "success"
+	case types.ReceiptStatusFailed:
+		return "failed"
+	}
+	return "unknown"
+}
@@ -0,0 +1,38 @@
+package communicator
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestParseReceipt(t *testing.T) {
+	receipt := &types.Receipt{
+		Status:            types.ReceiptStatusSuccessful,
+		CumulativeGasUsed: 52000,
+		GasUsed:           21000,
+		EffectiveGasPrice: big.NewInt(1000000000),
+		ContractAddress:   common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3"),
+		BlockNumber:       big.NewInt(12),
+		Logs: []*types.Log{
+			{
+				Address: common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3"),
+				Topics:  []common.Hash{common.HexToHash("0x01")},
+				Data:    []byte{0xde, 0xad},
+			},
+		},
+	}
+
+	parsed := parseReceipt(receipt)
+	if parsed.Status != "success" {
+		t.Errorf("Expected status 'success', got '%s'", parsed.Status)
+	}
+	if parsed.ContractAddress != "0x5FbDB2315678afecb367f032d93F642f64180aa3" {
+		t.Errorf("Unexpected contract address '%s'", parsed.ContractAddress)
+	}
+	if len(parsed.Logs) != 1 || parsed.Logs[0].Data != "0xdead" {
+		t.Errorf("Unexpected logs %v", parsed.Logs)
+	}
+}
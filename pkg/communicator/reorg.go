@@ -0,0 +1,138 @@
+package communicator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+const (
+	ChainResetReasonGenesisChanged = "genesis_changed" // node restarted or connected to another chain
+	ChainResetReasonHeadReversed   = "head_reversed"   // head number went backwards, e.g. evm_revert
+	ChainResetReasonReorg          = "reorg"           // block hashes changed without the head going backwards
+
+	maxRecentChainResets = 20
+)
+
+type ChainResetEvent struct {
+	Reason string `json:"reason"`
+
+	// Last indexed block before the reset
+	OldHead uint64 `json:"old_head"`
+
+	// Head of the node when the reset was detected
+	NewHead uint64 `json:"new_head"`
+
+	// Last block kept in the store, zero if everything was dropped or the ancestor is unknown
+	CommonAncestor uint64 `json:"common_ancestor"`
+	Timestamp      int64  `json:"timestamp"`
+}
+
+type GetChainResetsRequest struct{}
+
+type GetChainResetsResponse struct {
+	Resets []ChainResetEvent `json:"resets"`
+}
+
+// GetChainResets returns the recent chain resets detected by the indexer of the node.
+func GetChainResets(ctx context.Context, req GetChainResetsRequest) (GetChainResetsResponse, error) {
+	return getChainResets(ctx, req)
+}
+
+func getChainResets(ctx context.Context, _ GetChainResetsRequest) (GetChainResetsResponse, error) {
+	response := GetChainResetsResponse{
+		Resets: []ChainResetEvent{},
+	}
+
+	indexer := getIndexer(ctx)
+	if indexer == nil {
+		return response, nil
+	}
+
+	indexer.resetsMu.RLock()
+	defer indexer.resetsMu.RUnlock()
+	response.Resets = append(response.Resets, indexer.resets...)
+
+	return response, nil
+}
+
+// SubscribeChainResets delivers every chain reset detected by the indexer to the channel.
+func (i *Indexer) SubscribeChainResets(ch chan<- ChainResetEvent) event.Subscription {
+	return i.resetFeed.Subscribe(ch)
+}
+
+// detectReorg compares the stored blocks with the node from the stored head backwards and
+// returns the last block both agree on.
+func (i *Indexer) detectReorg(ctx context.Context, client *ethclient.Client, storedHead, head uint64) (uint64, bool, error) {
+	n := min(storedHead, head)
+	for ; n > 0; n-- {
+		storedBlock, ok, err := i.store.Block(n)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to get stored block %d: %v", n, err)
+		}
+		if !ok {
+			continue
+		}
+
+		header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(n))
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to get header %d: %v", n, err)
+		}
+		if header.Hash().Hex() == storedBlock.Header.Hash {
+			break
+		}
+	}
+
+	return n, n != storedHead, nil
+}
+
+// isConsistent reports whether the stored head was still part of the node's chain at the last sync.
+func (i *Indexer) isConsistent() bool {
+	return i.consistent.Load()
+}
+
+func (i *Indexer) rollback(ctx context.Context, reason string, oldHead, newHead, commonAncestor uint64) error {
+	slog.InfoContext(ctx, "Chain reset detected",
+		slog.Any("reason", reason),
+		slog.Any("old_head", oldHead),
+		slog.Any("new_head", newHead),
+		slog.Any("common_ancestor", commonAncestor),
+	)
+	if err := i.store.Rollback(commonAncestor); err != nil {
+		return fmt.Errorf("failed to roll back store to block %d: %v", commonAncestor, err)
+	}
+
+	i.emitChainReset(ChainResetEvent{
+		Reason:         reason,
+		OldHead:        oldHead,
+		NewHead:        newHead,
+		CommonAncestor: commonAncestor,
+		Timestamp:      time.Now().Unix(),
+	})
+	return nil
+}
+
+func (i *Indexer) emitChainReset(resetEvent ChainResetEvent) {
+	i.resetsMu.Lock()
+	i.resets = append(i.resets, resetEvent)
+	if len(i.resets) > maxRecentChainResets {
+		i.resets = i.resets[len(i.resets)-maxRecentChainResets:]
+	}
+	i.resetsMu.Unlock()
+
+	nodeChainChanged(i.nodeAddress)
+	i.resetFeed.Send(resetEvent)
+}
+
+// nodeChainChanged drops what is cached about the contracts of the node, after a chain reset or a
+// dev node call that changed the code on chain.
+func nodeChainChanged(nodeAddress string) {
+	if registry := getArtifactRegistry(); registry != nil {
+		registry.forgetNode(nodeAddress)
+	}
+}
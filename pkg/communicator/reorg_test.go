@@ -0,0 +1,50 @@
+package communicator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+)
+
+func TestIndexerConsistency(t *testing.T) {
+	chain := newTestChain(t, []*types.Transaction{}, []*types.Transaction{}, []*types.Transaction{})
+	ctx := newTestNode(t, map[string]interface{}{"eth": chain})
+	store := NewStore(memorydb.New())
+	defer store.Close()
+	indexer := NewIndexer(GetNodeAddress(ctx), store, 0)
+
+	// The store isn't used before the first sync
+	if indexer.isConsistent() {
+		t.Errorf("Expected an unsynced indexer to be inconsistent")
+	}
+	if err := indexer.sync(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if head, _, _ := store.Head(); head != 3 || !indexer.isConsistent() {
+		t.Errorf("Expected a consistent store with head 3, got head %d", head)
+	}
+
+	// A restarted node has a new genesis block, the store is wiped and reindexed
+	chain.setBlocks(newTestChain(t, []*types.Transaction{}).blocks)
+	if err := indexer.sync(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if head, _, _ := store.Head(); head != 1 || !indexer.isConsistent() {
+		t.Errorf("Expected a consistent store with head 1 after the restart, got head %d", head)
+	}
+	if len(indexer.resets) != 1 || indexer.resets[0].Reason != ChainResetReasonGenesisChanged {
+		t.Errorf("Expected a genesis change, got %v", indexer.resets)
+	}
+
+	// Failed syncs don't vouch for the store
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := indexer.Run(cancelled); err == nil {
+		t.Fatalf("Expected the cancelled run to return an error")
+	}
+	if indexer.isConsistent() {
+		t.Errorf("Expected the store to be inconsistent after a failed sync")
+	}
+}
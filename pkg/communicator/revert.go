@@ -0,0 +1,277 @@
+package communicator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	RevertTypeError   = "error"  // Error(string), e.g. require(cond, "message")
+	RevertTypePanic   = "panic"  // Panic(uint256), e.g. assert or arithmetic overflow
+	RevertTypeCustom  = "custom" // Custom error defined in the contract ABI
+	RevertTypeUnknown = "unknown"
+)
+
+var (
+	errorSelector = common.FromHex("0x08c379a0") // Error(string)
+	panicSelector = common.FromHex("0x4e487b71") // Panic(uint256)
+
+	panicReasons = map[uint64]string{
+		0x00: "generic compiler inserted panic",
+		0x01: "assertion failed",
+		0x11: "arithmetic underflow or overflow",
+		0x12: "division or modulo by zero",
+		0x21: "conversion into non-existent enum type",
+		0x22: "access to incorrectly encoded storage byte array",
+		0x31: "pop() on an empty array",
+		0x32: "array index out of bounds",
+		0x41: "too much memory allocated",
+		0x51: "call to zero-initialized function",
+	}
+)
+
+type DecodeRevertRequest struct {
+	ContractABI string `json:"contract_abi"`
+
+	// Used to look up the registered ABI if ContractABI is empty, defaults to the recipient of the transaction
+	ContractAddress string `json:"contract_address"`
+
+	// Revert data is taken from re-executing the transaction if it's set
+	TransactionHash string `json:"transaction_hash"`
+	Data            string `json:"data"`
+}
+
+type RevertReason struct {
+	Type      string                 `json:"type"`
+	Message   string                 `json:"message"`
+	ErrorName string                 `json:"error_name,omitempty"`
+	Signature string                 `json:"signature,omitempty"`
+	Args      map[string]interface{} `json:"args,omitempty"`
+	PanicCode string                 `json:"panic_code,omitempty"`
+	Data      string                 `json:"data"`
+
+	// Set if the node can't trace the transaction and it was replayed on its parent block instead,
+	// without the earlier transactions of the same block
+	Approximate bool `json:"approximate,omitempty"`
+}
+
+func DecodeRevert(ctx context.Context, req DecodeRevertRequest) (RevertReason, error) {
+	return decodeRevertRequest(ctx, req)
+}
+
+func decodeRevertRequest(ctx context.Context, req DecodeRevertRequest) (RevertReason, error) {
+	var contractABI *abi.ABI
+	if req.ContractABI != "" {
+		parsedABI, err := abi.JSON(strings.NewReader(req.ContractABI))
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to parse contract ABI", slog.Any("err", err))
+			return RevertReason{}, fmt.Errorf("failed to parse ABI: %v", err)
+		}
+		contractABI = &parsedABI
+	}
+
+	if req.TransactionHash == "" {
+		if contractABI == nil && req.ContractAddress != "" {
+			contractABI = registeredContractABI(ctx, common.HexToAddress(req.ContractAddress))
+		}
+		return decodeRevert(contractABI, common.FromHex(req.Data)), nil
+	}
+
+	client, err := ethclient.DialContext(ctx, GetNodeAddress(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to connect to Ethereum client", slog.Any("err", err))
+		return RevertReason{}, err
+	}
+
+	transaction, _, err := client.TransactionByHash(ctx, common.HexToHash(req.TransactionHash))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get transaction by hash", slog.Any("hash", req.TransactionHash), slog.Any("err", err))
+		return RevertReason{}, err
+	}
+	receipt, err := client.TransactionReceipt(ctx, transaction.Hash())
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get transaction receipt", slog.Any("hash", req.TransactionHash), slog.Any("err", err))
+		return RevertReason{}, err
+	}
+	if receipt.Status != types.ReceiptStatusFailed {
+		return RevertReason{}, fmt.Errorf("transaction %s didn't fail", req.TransactionHash)
+	}
+
+	if contractABI == nil {
+		contractAddress := transaction.To()
+		if req.ContractAddress != "" {
+			address := common.HexToAddress(req.ContractAddress)
+			contractAddress = &address
+		}
+		if contractAddress != nil {
+			contractABI = registeredContractABI(ctx, *contractAddress)
+		}
+	}
+
+	revert, err := replayRevert(ctx, client, transaction, receipt.BlockNumber, contractABI)
+	if err != nil {
+		return RevertReason{}, err
+	}
+	return *revert, nil
+}
+
+// replayRevert gets the revert data of a failed transaction from its trace, or by re-executing it on
+// the state of its parent block if the node doesn't support debug_traceTransaction.
+func replayRevert(ctx context.Context, client *ethclient.Client, transaction *types.Transaction, blockNumber *big.Int, contractABI *abi.ABI) (*RevertReason, error) {
+	var frame callTracerFrame
+	err := client.Client().CallContext(ctx, &frame, "debug_traceTransaction", transaction.Hash(), map[string]interface{}{
+		"tracer": "callTracer",
+	})
+	if err == nil {
+		if frame.Error == "" {
+			return nil, fmt.Errorf("transaction %s didn't revert when traced", transaction.Hash().Hex())
+		}
+		if len(frame.Output) == 0 && frame.Error != vm.ErrExecutionReverted.Error() {
+			// Out of gas and similar failures don't carry revert data
+			return &RevertReason{
+				Type:    RevertTypeUnknown,
+				Message: frame.Error,
+				Data:    "0x",
+			}, nil
+		}
+		reason := decodeRevert(contractABI, frame.Output)
+		return &reason, nil
+	}
+	slog.DebugContext(ctx, "Failed to trace transaction, replaying it on the parent block", slog.Any("hash", transaction.Hash()), slog.Any("err", err))
+
+	chainID := transaction.ChainId()
+	if chainID == nil || chainID.Sign() == 0 {
+		chainID = big.NewInt(1)
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), transaction)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction sender: %v", err)
+	}
+
+	_, err = client.CallContract(ctx, ethereum.CallMsg{
+		From:  from,
+		To:    transaction.To(),
+		Gas:   transaction.Gas(),
+		Value: transaction.Value(),
+		Data:  transaction.Data(),
+	}, new(big.Int).Sub(blockNumber, big.NewInt(1)))
+	if err == nil {
+		return nil, fmt.Errorf("transaction %s didn't revert when replayed", transaction.Hash().Hex())
+	}
+
+	data, ok := revertDataFromError(err)
+	if !ok {
+		return &RevertReason{
+			Type:        RevertTypeUnknown,
+			Message:     err.Error(),
+			Data:        "0x",
+			Approximate: true,
+		}, nil
+	}
+	reason := decodeRevert(contractABI, data)
+	reason.Approximate = true
+	return &reason, nil
+}
+
+// revertDataFromError extracts the revert data from a JSON-RPC error.
+func revertDataFromError(err error) ([]byte, bool) {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return nil, false
+	}
+
+	switch data := dataErr.ErrorData().(type) {
+	case string:
+		decoded, err := hexutil.Decode(data)
+		return decoded, err == nil
+	case map[string]interface{}:
+		// Some hardhat versions wrap the data into an object
+		if str, ok := data["data"].(string); ok {
+			decoded, err := hexutil.Decode(str)
+			return decoded, err == nil
+		}
+	}
+	return nil, false
+}
+
+// decodeRevert decodes Error(string), Panic(uint256) and the custom errors of the ABI.
+func decodeRevert(contractABI *abi.ABI, data []byte) RevertReason {
+	reason := RevertReason{
+		Type: RevertTypeUnknown,
+		Data: hexutil.Encode(data),
+	}
+	if len(data) == 0 {
+		reason.Message = "execution reverted without reason"
+		return reason
+	}
+	if len(data) < 4 {
+		reason.Message = "invalid revert data"
+		return reason
+	}
+
+	selector := data[:4]
+	switch {
+	case bytes.Equal(selector, errorSelector):
+		message, err := abi.UnpackRevert(data)
+		if err != nil {
+			reason.Message = fmt.Sprintf("failed to decode Error(string): %v", err)
+			return reason
+		}
+		reason.Type = RevertTypeError
+		reason.Signature = "Error(string)"
+		reason.Message = message
+		return reason
+	case bytes.Equal(selector, panicSelector):
+		if len(data) != 36 {
+			reason.Message = "invalid Panic(uint256) data"
+			return reason
+		}
+		code := new(big.Int).SetBytes(data[4:])
+		reason.Type = RevertTypePanic
+		reason.Signature = "Panic(uint256)"
+		reason.PanicCode = fmt.Sprintf("%#x", code)
+		reason.Message = fmt.Sprintf("unknown panic code %#x", code)
+		if code.IsUint64() {
+			if message, ok := panicReasons[code.Uint64()]; ok {
+				reason.Message = message
+			}
+		}
+		return reason
+	}
+
+	if contractABI != nil {
+		for _, abiError := range contractABI.Errors {
+			if !bytes.Equal(abiError.ID[:4], selector) {
+				continue
+			}
+			args, err := unpackToJSON(abiError.Inputs, data[4:], "arg")
+			if err != nil {
+				reason.Message = fmt.Sprintf("failed to decode %s: %v", abiError.Sig, err)
+				return reason
+			}
+			reason.Type = RevertTypeCustom
+			reason.ErrorName = abiError.Name
+			reason.Signature = abiError.Sig
+			reason.Args = args
+			reason.Message = abiError.Sig
+			return reason
+		}
+	}
+
+	reason.Message = fmt.Sprintf("unknown error with selector %s", hexutil.Encode(selector))
+	return reason
+}
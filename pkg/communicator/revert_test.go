@@ -0,0 +1,142 @@
+package communicator
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const (
+	// Contract ABI with a custom error for testing
+	customErrorContractABI = `[{"inputs":[{"name":"available","type":"uint256"},{"name":"required","type":"uint256"}],"name":"InsufficientBalance","type":"error"}]`
+
+	// Error("Not the owner")
+	notTheOwnerRevertData = "0x08c379a00000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000000d4e6f7420746865206f776e657200000000000000000000000000000000000000"
+)
+
+func TestDecodeRevert(t *testing.T) {
+	contractABI, err := abi.JSON(strings.NewReader(customErrorContractABI))
+	if err != nil {
+		t.Fatalf("Failed to parse ABI: %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		data            string
+		expectedType    string
+		expectedMessage string
+	}{
+		{
+			name:            "Error(string)",
+			data:            "0x08c379a00000000000000000000000000000000000000000000000000000000000000020000000000000000000000000000000000000000000000000000000000000000d4e6f7420746865206f776e657200000000000000000000000000000000000000",
+			expectedType:    RevertTypeError,
+			expectedMessage: "Not the owner",
+		},
+		{
+			name:            "Panic(uint256)",
+			data:            "0x4e487b710000000000000000000000000000000000000000000000000000000000000011",
+			expectedType:    RevertTypePanic,
+			expectedMessage: "arithmetic underflow or overflow",
+		},
+		{
+			name:            "custom error",
+			data:            "0xcf4791810000000000000000000000000000000000000000000000000000000000000001000000000000000000000000000000000000000000000000000000000000000a",
+			expectedType:    RevertTypeCustom,
+			expectedMessage: "InsufficientBalance(uint256,uint256)",
+		},
+		{
+			name:            "empty",
+			data:            "0x",
+			expectedType:    RevertTypeUnknown,
+			expectedMessage: "execution reverted without reason",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := decodeRevert(&contractABI, common.FromHex(tt.data))
+			if reason.Type != tt.expectedType || reason.Message != tt.expectedMessage {
+				t.Errorf("Expected %s '%s', got %s '%s'", tt.expectedType, tt.expectedMessage, reason.Type, reason.Message)
+			}
+		})
+	}
+}
+
+// revertTestNode reverts every call with Error("Not the owner"), the trace is only served if it's set.
+type revertTestNode struct {
+	trace *callTracerFrame
+}
+
+type revertTestError struct{}
+
+func (revertTestError) Error() string          { return "execution reverted: Not the owner" }
+func (revertTestError) ErrorCode() int         { return 3 }
+func (revertTestError) ErrorData() interface{} { return notTheOwnerRevertData }
+
+func (n *revertTestNode) Call(args map[string]interface{}, block json.RawMessage) (hexutil.Bytes, error) {
+	return nil, revertTestError{}
+}
+
+func (n *revertTestNode) TraceTransaction(hash common.Hash, config map[string]interface{}) (*callTracerFrame, error) {
+	return n.trace, nil
+}
+
+func TestReplayRevert(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	transaction := newTestTransaction(t, key, 0, common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3"))
+
+	tests := []struct {
+		name                string
+		trace               *callTracerFrame
+		expectedMessage     string
+		expectedApproximate bool
+	}{
+		{
+			name:            "traced revert",
+			trace:           &callTracerFrame{Type: "CALL", Error: "execution reverted", Output: common.FromHex(notTheOwnerRevertData)},
+			expectedMessage: "Not the owner",
+		},
+		{
+			name:            "traced out of gas",
+			trace:           &callTracerFrame{Type: "CALL", Error: "out of gas"},
+			expectedMessage: "out of gas",
+		},
+		{
+			name:                "replayed without tracing",
+			expectedMessage:     "Not the owner",
+			expectedApproximate: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &revertTestNode{trace: tt.trace}
+			services := map[string]interface{}{"eth": node}
+			if tt.trace != nil {
+				services["debug"] = node
+			}
+			ctx := newTestNode(t, services)
+			client, err := ethclient.DialContext(ctx, GetNodeAddress(ctx))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			reason, err := replayRevert(ctx, client, transaction, big.NewInt(1), nil)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if reason.Message != tt.expectedMessage || reason.Approximate != tt.expectedApproximate {
+				t.Errorf("Expected '%s' (approximate %v), got '%s' (approximate %v)", tt.expectedMessage, tt.expectedApproximate, reason.Message, reason.Approximate)
+			}
+		})
+	}
+}
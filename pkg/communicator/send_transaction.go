@@ -2,27 +2,89 @@ package communicator
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
-	"log"
 	"log/slog"
+	"math"
 	"math/big"
+	"sort"
+	"strings"
 
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
+const (
+	TxTypeLegacy     = "legacy"
+	TxTypeAccessList = "access_list"
+	TxTypeDynamicFee = "dynamic_fee"
+
+	// The priority fee is the median tip of the last blocks
+	feeHistoryBlocks     = 10
+	feeHistoryPercentile = 50
+
+	// Leaves room for state changes between the estimation and the inclusion
+	defaultGasMultiplier = 1.2
+)
+
 type SendTransactionRequest struct {
-	Method          string   `json:"method"`
-	ContractAddress string   `json:"contract_address"`
-	ContractABI     string   `json:"contract_abi"`
-	PrivateKeyHex   string   `json:"private_key"` // without "0x" prefix
-	Input           []string `json:"input"`       // input parameters for the method
+	Method          string    `json:"method"`
+	ContractAddress string    `json:"contract_address"`
+	ContractABI     string    `json:"contract_abi"` // Registered ABI of the contract is used if empty
+	Signer          string    `json:"signer"`       // name or address of a registered or node account
+	PrivateKeyHex   string    `json:"private_key"`  // used if no signer is set, without "0x" prefix
+	Input           Arguments `json:"input"`        // input parameters for the method
+
+	// Address the dev node sends from after impersonating it, no signer is needed
+	Impersonate string `json:"impersonate"`
+
+	// Address dry runs are simulated from, no signer is needed
+	From string `json:"from"`
+
+	// legacy, access_list or dynamic_fee (2930 and 1559 are accepted too), dynamic_fee if the node supports it by default
+	Type  string  `json:"type"`
+	Value string  `json:"value"` // wei, decimal or 0x prefixed hex
+	Nonce *uint64 `json:"nonce"` // pending nonce of the sender if not set
+
+	// Fees are suggested by the node if empty, dynamic fees are based on eth_feeHistory
+	GasPrice             string `json:"gas_price"`
+	MaxFeePerGas         string `json:"max_fee_per_gas"`
+	MaxPriorityFeePerGas string `json:"max_priority_fee_per_gas"`
+
+	AccessList []AccessTuple `json:"access_list"`
+	// The access list is generated by eth_createAccessList instead of using AccessList
+	CreateAccessList bool `json:"create_access_list"`
+
+	// The estimate of eth_estimateGas multiplied by GasMultiplier (1.2 by default) is used if GasLimit is not set
+	GasLimit      uint64  `json:"gas_limit"`
+	GasMultiplier float64 `json:"gas_multiplier"`
+
+	// The transaction is simulated with eth_call at the pending block and not sent
+	DryRun bool `json:"dry_run"`
+}
+
+type AccessTuple struct {
+	Address     string   `json:"address"`
+	StorageKeys []string `json:"storage_keys"`
 }
 
 type SendTransactionResponse struct {
-	TransactionHash string `json:"transaction_hash"`
+	TransactionHash      string        `json:"transaction_hash,omitempty"`
+	Type                 string        `json:"type"`
+	Nonce                uint64        `json:"nonce"`
+	GasPrice             string        `json:"gas_price,omitempty"`
+	MaxFeePerGas         string        `json:"max_fee_per_gas,omitempty"`
+	MaxPriorityFeePerGas string        `json:"max_priority_fee_per_gas,omitempty"`
+	AccessList           []AccessTuple `json:"access_list,omitempty"`
+	GasEstimate          uint64        `json:"gas_estimate"`
+	GasLimit             uint64        `json:"gas_limit"`
+
+	// Outcome of the dry run, the decoded return values or the revert reason
+	Simulation *ETHCallResponse `json:"simulation,omitempty"`
 }
 
 func SendTransaction(ctx context.Context, req SendTransactionRequest) (SendTransactionResponse, error) {
@@ -30,70 +92,425 @@ func SendTransaction(ctx context.Context, req SendTransactionRequest) (SendTrans
 }
 
 func sendTransaction(ctx context.Context, req SendTransactionRequest) (SendTransactionResponse, error) {
+	txType, err := parseTxType(req.Type)
+	if err != nil {
+		return SendTransactionResponse{}, err
+	}
+	value, err := parseWei("value", req.Value)
+	if err != nil {
+		return SendTransactionResponse{}, err
+	}
+	if value == nil {
+		value = new(big.Int)
+	}
+	accessList, err := parseAccessList(req.AccessList)
+	if err != nil {
+		return SendTransactionResponse{}, err
+	}
+	gasMultiplier := req.GasMultiplier
+	if gasMultiplier == 0 {
+		gasMultiplier = defaultGasMultiplier
+	}
+	if gasMultiplier < 1 {
+		return SendTransactionResponse{}, fmt.Errorf("gas multiplier has to be at least 1, got %v", gasMultiplier)
+	}
+
 	client, err := ethclient.DialContext(ctx, GetNodeAddress(ctx))
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to connect to Ethereum client", slog.Any("err", err))
 		return SendTransactionResponse{}, err
 	}
+	defer client.Close()
+
+	var sender *signer
+	var fromAddress common.Address
+	switch {
+	case req.From != "":
+		if !req.DryRun {
+			return SendTransactionResponse{}, fmt.Errorf("from is only used by dry runs, sending needs a signer")
+		}
+		if req.Signer != "" || req.PrivateKeyHex != "" || req.Impersonate != "" {
+			return SendTransactionResponse{}, fmt.Errorf("either from or a signer has to be set, not both")
+		}
+		if !common.IsHexAddress(req.From) {
+			return SendTransactionResponse{}, fmt.Errorf("invalid from address %q", req.From)
+		}
+		fromAddress = common.HexToAddress(req.From)
+	case req.Impersonate != "":
+		if req.Signer != "" || req.PrivateKeyHex != "" {
+			return SendTransactionResponse{}, fmt.Errorf("either a signer or an impersonated account has to be set, not both")
+		}
+		var cleanup func()
+		sender, cleanup, err = impersonatedSigner(ctx, req.Impersonate)
+		if err != nil {
+			return SendTransactionResponse{}, err
+		}
+		defer cleanup()
+		fromAddress = sender.address
+	default:
+		sender, err = resolveSigner(ctx, client, req.Signer, req.PrivateKeyHex)
+		if err != nil {
+			return SendTransactionResponse{}, err
+		}
+		fromAddress = sender.address
+	}
 
-	privateKey, err := crypto.HexToECDSA(req.PrivateKeyHex)
+	// Contract address
+	contractAddress := common.HexToAddress(req.ContractAddress)
+
+	contractABI, err := resolveContractABI(ctx, req.ContractABI, contractAddress)
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to convert private key from hex", slog.Any("err", err))
-		return SendTransactionResponse{}, fmt.Errorf("failed to convert private key from hex: %v", err)
+		return SendTransactionResponse{}, err
 	}
 
-	// Derive sender address
-	fromAddress := crypto.PubkeyToAddress(privateKey.PublicKey)
+	callData, method, err := getCallData(ctx, contractABI, req.Method, req.Input)
+	if err != nil {
+		return SendTransactionResponse{}, fmt.Errorf("failed to get call data: %v", err)
+	}
+	if value.Sign() > 0 && !method.IsPayable() {
+		return SendTransactionResponse{}, fmt.Errorf("method %s is not payable", method.Name)
+	}
 
-	// Get the nonce
-	nonce, err := client.PendingNonceAt(ctx, fromAddress)
+	chainID, err := client.ChainID(ctx)
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to get nonce", slog.Any("err", err))
-		return SendTransactionResponse{}, fmt.Errorf("failed to get nonce: %v", err)
+		slog.ErrorContext(ctx, "Failed to get chain ID", slog.Any("err", err))
+		return SendTransactionResponse{}, fmt.Errorf("failed to get chain ID: %v", err)
+	}
+
+	// Get the nonce
+	var nonce uint64
+	if req.Nonce != nil {
+		nonce = *req.Nonce
+	} else {
+		nonce, err = client.PendingNonceAt(ctx, fromAddress)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to get nonce", slog.Any("err", err))
+			return SendTransactionResponse{}, fmt.Errorf("failed to get nonce: %v", err)
+		}
+	}
+
+	if txType == "" {
+		header, err := client.HeaderByNumber(ctx, nil)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to get latest header", slog.Any("err", err))
+			return SendTransactionResponse{}, fmt.Errorf("failed to get latest header: %v", err)
+		}
+		txType = TxTypeLegacy
+		if header.BaseFee != nil {
+			txType = TxTypeDynamicFee
+		}
+	}
+	if txType == TxTypeLegacy && (len(accessList) > 0 || req.CreateAccessList) {
+		return SendTransactionResponse{}, fmt.Errorf("legacy transactions can't have an access list")
+	}
+
+	if req.CreateAccessList {
+		accessList, err = createAccessList(ctx, client, ethereum.CallMsg{
+			From:  fromAddress,
+			To:    &contractAddress,
+			Value: value,
+			Data:  callData,
+		})
+		if err != nil {
+			return SendTransactionResponse{}, err
+		}
+	}
+
+	response := SendTransactionResponse{
+		Type:       txType,
+		Nonce:      nonce,
+		AccessList: toAccessTuples(accessList),
+	}
+	msg := ethereum.CallMsg{
+		From:       fromAddress,
+		To:         &contractAddress,
+		Value:      value,
+		Data:       callData,
+		AccessList: accessList,
+	}
+
+	if req.DryRun {
+		simulation, err := simulateTransaction(ctx, client, contractABI, method, msg)
+		if err != nil {
+			return SendTransactionResponse{}, err
+		}
+		response.Simulation = &simulation
+		if simulation.Revert != nil {
+			return response, nil
+		}
 	}
 
 	// Gas parameters
-	gasLimit := uint64(100000)
-	gasPrice, err := client.SuggestGasPrice(ctx)
+	gasLimit := req.GasLimit
+	if gasLimit == 0 {
+		estimate, err := client.EstimateGas(ctx, msg)
+		if err != nil {
+			if revertData, ok := revertDataFromError(err); ok {
+				revert := decodeRevert(&contractABI, revertData)
+				return SendTransactionResponse{}, fmt.Errorf("transaction would revert: %s", revert.Message)
+			}
+			slog.ErrorContext(ctx, "Failed to estimate gas", slog.Any("method", req.Method), slog.Any("err", err))
+			return SendTransactionResponse{}, fmt.Errorf("failed to estimate gas: %v", err)
+		}
+		response.GasEstimate = estimate
+		gasLimit = applyGasMultiplier(estimate, gasMultiplier)
+	}
+	response.GasLimit = gasLimit
+	if req.DryRun {
+		return response, nil
+	}
+	var txData types.TxData
+	switch txType {
+	case TxTypeDynamicFee:
+		maxFee, tip, err := suggestDynamicFees(ctx, client, req.MaxFeePerGas, req.MaxPriorityFeePerGas)
+		if err != nil {
+			return SendTransactionResponse{}, err
+		}
+		response.MaxFeePerGas = maxFee.String()
+		response.MaxPriorityFeePerGas = tip.String()
+		txData = &types.DynamicFeeTx{
+			ChainID:    chainID,
+			Nonce:      nonce,
+			GasTipCap:  tip,
+			GasFeeCap:  maxFee,
+			Gas:        gasLimit,
+			To:         &contractAddress,
+			Value:      value,
+			Data:       callData,
+			AccessList: accessList,
+		}
+	default:
+		gasPrice, err := suggestGasPrice(ctx, client, req.GasPrice)
+		if err != nil {
+			return SendTransactionResponse{}, err
+		}
+		response.GasPrice = gasPrice.String()
+		if txType == TxTypeAccessList {
+			txData = &types.AccessListTx{
+				ChainID:    chainID,
+				Nonce:      nonce,
+				GasPrice:   gasPrice,
+				Gas:        gasLimit,
+				To:         &contractAddress,
+				Value:      value,
+				Data:       callData,
+				AccessList: accessList,
+			}
+		} else {
+			txData = &types.LegacyTx{
+				Nonce:    nonce,
+				GasPrice: gasPrice,
+				Gas:      gasLimit,
+				To:       &contractAddress,
+				Value:    value,
+				Data:     callData,
+			}
+		}
+	}
+
+	hash, err := sender.sendTransaction(ctx, client, chainID, txData)
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to suggest gas price", slog.Any("err", err))
-		return SendTransactionResponse{}, fmt.Errorf("failed to suggest gas price: %v", err)
+		return SendTransactionResponse{}, err
 	}
 
-	// Contract address
-	contractAddress := common.HexToAddress(req.ContractAddress)
+	response.TransactionHash = hash.Hex()
+	return response, nil
+}
 
-	callData, _, err := getCallData(ctx, req.ContractABI, req.Method, req.Input)
+// simulateTransaction executes the call at the pending block, a revert is part of the result and not an error.
+func simulateTransaction(ctx context.Context, client *ethclient.Client, contractABI abi.ABI, method abi.Method, msg ethereum.CallMsg) (ETHCallResponse, error) {
+	result, err := client.PendingCallContract(ctx, msg)
 	if err != nil {
-		return SendTransactionResponse{}, fmt.Errorf("failed to get call data: %v", err)
+		if revertData, ok := revertDataFromError(err); ok {
+			slog.InfoContext(ctx, "Simulated transaction reverted", slog.Any("method", method.Name), slog.Any("err", err))
+			revert := decodeRevert(&contractABI, revertData)
+			return ETHCallResponse{
+				RawResponse: hex.EncodeToString(revertData),
+				Revert:      &revert,
+			}, nil
+		}
+		slog.ErrorContext(ctx, "Failed to simulate transaction", slog.Any("method", method.Name), slog.Any("err", err))
+		return ETHCallResponse{}, fmt.Errorf("failed to simulate transaction: %v", err)
 	}
 
-	// Create transaction
-	tx := types.NewTransaction(nonce, contractAddress, big.NewInt(0), gasLimit, gasPrice, callData)
-
-	chainID, err := client.ChainID(ctx)
+	decoded, err := parseResult(ctx, method, result)
 	if err != nil {
-		log.Fatal("Failed to get chain ID:", err)
+		slog.ErrorContext(ctx, "Failed to parse result", slog.Any("method", method.Name), slog.Any("err", err))
+		return ETHCallResponse{}, fmt.Errorf("failed to parse result: %v", err)
+	}
+	return ETHCallResponse{
+		RawResponse: hex.EncodeToString(result),
+		Decoded:     decoded,
+	}, nil
+}
+
+func applyGasMultiplier(estimate uint64, multiplier float64) uint64 {
+	return uint64(math.Ceil(float64(estimate) * multiplier))
+}
+
+func parseTxType(str string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(str)) {
+	case "":
+		return "", nil
+	case TxTypeLegacy, "0", "0x0":
+		return TxTypeLegacy, nil
+	case TxTypeAccessList, "2930", "1", "0x1":
+		return TxTypeAccessList, nil
+	case TxTypeDynamicFee, "1559", "2", "0x2":
+		return TxTypeDynamicFee, nil
+	}
+	return "", fmt.Errorf("unsupported transaction type %q", str)
+}
+
+// parseWei parses an optional non-negative amount, nil is returned for an empty string.
+func parseWei(name, str string) (*big.Int, error) {
+	if strings.TrimSpace(str) == "" {
+		return nil, nil
+	}
+	amount, err := parseBigInt(str)
+	if err != nil || amount.Sign() < 0 {
+		return nil, fmt.Errorf("invalid %s %q", name, str)
+	}
+	return amount, nil
+}
+
+func parseAccessList(tuples []AccessTuple) (types.AccessList, error) {
+	accessList := make(types.AccessList, 0, len(tuples))
+	for _, tuple := range tuples {
+		if !common.IsHexAddress(tuple.Address) {
+			return nil, fmt.Errorf("invalid access list address %q", tuple.Address)
+		}
+		keys := make([]common.Hash, 0, len(tuple.StorageKeys))
+		for _, key := range tuple.StorageKeys {
+			decoded, err := hexutil.Decode(key)
+			if err != nil || len(decoded) > common.HashLength {
+				return nil, fmt.Errorf("invalid access list storage key %q", key)
+			}
+			keys = append(keys, common.BytesToHash(decoded))
+		}
+		accessList = append(accessList, types.AccessTuple{
+			Address:     common.HexToAddress(tuple.Address),
+			StorageKeys: keys,
+		})
+	}
+	return accessList, nil
+}
+
+func toAccessTuples(accessList types.AccessList) []AccessTuple {
+	tuples := make([]AccessTuple, 0, len(accessList))
+	for _, tuple := range accessList {
+		keys := make([]string, 0, len(tuple.StorageKeys))
+		for _, key := range tuple.StorageKeys {
+			keys = append(keys, key.Hex())
+		}
+		tuples = append(tuples, AccessTuple{
+			Address:     tuple.Address.Hex(),
+			StorageKeys: keys,
+		})
 	}
-	if chainID == nil {
-		chainID = big.NewInt(1)
+	return tuples
+}
+
+type accessListResult struct {
+	AccessList types.AccessList `json:"accessList"`
+	GasUsed    hexutil.Uint64   `json:"gasUsed"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// createAccessList asks the node for the accounts and storage slots touched by the call at the pending block.
+func createAccessList(ctx context.Context, client *ethclient.Client, msg ethereum.CallMsg) (types.AccessList, error) {
+	arg := map[string]interface{}{
+		"from":  msg.From,
+		"to":    msg.To,
+		"input": hexutil.Bytes(msg.Data),
+		"value": (*hexutil.Big)(msg.Value),
 	}
 
-	// Sign it
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), privateKey)
+	var result accessListResult
+	if err := client.Client().CallContext(ctx, &result, "eth_createAccessList", arg, "pending"); err != nil {
+		slog.ErrorContext(ctx, "Failed to create access list", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to create access list: %v", err)
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("failed to create access list: %s", result.Error)
+	}
+	return result.AccessList, nil
+}
+
+func suggestGasPrice(ctx context.Context, client *ethclient.Client, gasPriceStr string) (*big.Int, error) {
+	gasPrice, err := parseWei("gas price", gasPriceStr)
+	if err != nil || gasPrice != nil {
+		return gasPrice, err
+	}
+	gasPrice, err = client.SuggestGasPrice(ctx)
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to sign transaction", slog.Any("err", err))
-		return SendTransactionResponse{}, fmt.Errorf("failed to sign transaction: %v", err)
+		slog.ErrorContext(ctx, "Failed to suggest gas price", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to suggest gas price: %v", err)
 	}
+	return gasPrice, nil
+}
 
-	// Send the transaction
-	err = client.SendTransaction(ctx, signedTx)
+// suggestDynamicFees fills the missing fee caps, the max fee covers the next base fee doubling.
+func suggestDynamicFees(ctx context.Context, client *ethclient.Client, maxFeeStr, tipStr string) (*big.Int, *big.Int, error) {
+	maxFee, err := parseWei("max fee per gas", maxFeeStr)
 	if err != nil {
-		slog.ErrorContext(ctx, "Failed to send transaction", slog.Any("err", err))
-		return SendTransactionResponse{}, fmt.Errorf("failed to send transaction: %v", err)
+		return nil, nil, err
+	}
+	tip, err := parseWei("max priority fee per gas", tipStr)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return SendTransactionResponse{
-		TransactionHash: signedTx.Hash().Hex(),
-	}, nil
+	if maxFee == nil || tip == nil {
+		history, err := client.FeeHistory(ctx, feeHistoryBlocks, nil, []float64{feeHistoryPercentile})
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to get fee history", slog.Any("err", err))
+			return nil, nil, fmt.Errorf("failed to get fee history: %v", err)
+		}
+		baseFee, suggestedTip := feesFromHistory(history)
+		if suggestedTip == nil {
+			suggestedTip, err = client.SuggestGasTipCap(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "Failed to suggest gas tip cap", slog.Any("err", err))
+				return nil, nil, fmt.Errorf("failed to suggest gas tip cap: %v", err)
+			}
+		}
+
+		if tip == nil {
+			tip = suggestedTip
+			if maxFee != nil && tip.Cmp(maxFee) > 0 {
+				tip = new(big.Int).Set(maxFee)
+			}
+		}
+		if maxFee == nil {
+			maxFee = new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tip)
+		}
+	}
+
+	if tip.Cmp(maxFee) > 0 {
+		return nil, nil, fmt.Errorf("max priority fee per gas %s is higher than max fee per gas %s", tip, maxFee)
+	}
+	return maxFee, tip, nil
+}
+
+// feesFromHistory returns the base fee of the next block and the median of the rewards, nil if there were none.
+func feesFromHistory(history *ethereum.FeeHistory) (*big.Int, *big.Int) {
+	baseFee := new(big.Int)
+	if len(history.BaseFee) > 0 && history.BaseFee[len(history.BaseFee)-1] != nil {
+		baseFee = history.BaseFee[len(history.BaseFee)-1]
+	}
+
+	var rewards []*big.Int
+	for _, reward := range history.Reward {
+		if len(reward) > 0 && reward[0] != nil {
+			rewards = append(rewards, reward[0])
+		}
+	}
+	if len(rewards) == 0 {
+		return baseFee, nil
+	}
+	sort.Slice(rewards, func(i, j int) bool {
+		return rewards[i].Cmp(rewards[j]) < 0
+	})
+	return baseFee, rewards[len(rewards)/2]
 }
@@ -0,0 +1,156 @@
+package communicator
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func TestParseTxType(t *testing.T) {
+	tests := map[string]string{
+		"":            "",
+		"legacy":      TxTypeLegacy,
+		"2930":        TxTypeAccessList,
+		"access_list": TxTypeAccessList,
+		"1559":        TxTypeDynamicFee,
+		"0x2":         TxTypeDynamicFee,
+	}
+	for input, expected := range tests {
+		got, err := parseTxType(input)
+		if err != nil {
+			t.Errorf("Expected no error for %q, got %v", input, err)
+		}
+		if got != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, input, got)
+		}
+	}
+
+	if _, err := parseTxType("blob"); err == nil {
+		t.Errorf("Expected error for unsupported type")
+	}
+}
+
+func TestFeesFromHistory(t *testing.T) {
+	baseFee, tip := feesFromHistory(&ethereum.FeeHistory{
+		BaseFee: []*big.Int{big.NewInt(7), big.NewInt(8), big.NewInt(9)},
+		Reward: [][]*big.Int{
+			{big.NewInt(3)},
+			{big.NewInt(1)},
+			{},
+			{big.NewInt(2)},
+		},
+	})
+	if baseFee.Int64() != 9 {
+		t.Errorf("Expected base fee of the next block 9, got %s", baseFee)
+	}
+	if tip == nil || tip.Int64() != 2 {
+		t.Errorf("Expected median tip 2, got %v", tip)
+	}
+
+	_, tip = feesFromHistory(&ethereum.FeeHistory{BaseFee: []*big.Int{big.NewInt(1)}})
+	if tip != nil {
+		t.Errorf("Expected no tip without rewards, got %s", tip)
+	}
+}
+
+func TestParseAccessList(t *testing.T) {
+	accessList, err := parseAccessList([]AccessTuple{{
+		Address:     "0x5FbDB2315678afecb367f032d93F642f64180aa3",
+		StorageKeys: []string{"0x01"},
+	}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	tuples := toAccessTuples(accessList)
+	if len(tuples) != 1 || len(tuples[0].StorageKeys) != 1 {
+		t.Fatalf("Expected one tuple with one key, got %v", tuples)
+	}
+	if key := tuples[0].StorageKeys[0]; key != "0x0000000000000000000000000000000000000000000000000000000000000001" {
+		t.Errorf("Expected padded storage key, got %s", key)
+	}
+
+	if _, err := parseAccessList([]AccessTuple{{Address: "0x01"}}); err == nil {
+		t.Errorf("Expected error for invalid address")
+	}
+}
+
+func TestApplyGasMultiplier(t *testing.T) {
+	tests := []struct {
+		estimate   uint64
+		multiplier float64
+		expected   uint64
+	}{
+		{estimate: 21000, multiplier: 1, expected: 21000},
+		{estimate: 100000, multiplier: 1.2, expected: 120000},
+		{estimate: 33333, multiplier: 1.5, expected: 50000},
+		{estimate: 3, multiplier: 1.1, expected: 4},
+	}
+	for _, test := range tests {
+		if got := applyGasMultiplier(test.estimate, test.multiplier); got != test.expected {
+			t.Errorf("Expected %d for %d * %v, got %d", test.expected, test.estimate, test.multiplier, got)
+		}
+	}
+}
+
+// dryRunTestNode simulates every call successfully and records the sender
+type dryRunTestNode struct {
+	from string
+}
+
+func (n *dryRunTestNode) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(31337))
+}
+
+func (n *dryRunTestNode) GetTransactionCount(address common.Address, block string) hexutil.Uint64 {
+	return 7
+}
+
+func (n *dryRunTestNode) Call(args map[string]interface{}, block json.RawMessage) hexutil.Bytes {
+	n.from, _ = args["from"].(string)
+	return common.LeftPadBytes([]byte{1}, 32)
+}
+
+func (n *dryRunTestNode) EstimateGas(args map[string]interface{}) hexutil.Uint64 {
+	return 50000
+}
+
+func TestSendTransactionDryRunFrom(t *testing.T) {
+	node := &dryRunTestNode{}
+	ctx := newTestNode(t, map[string]interface{}{"eth": node})
+	from := "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"
+	req := SendTransactionRequest{
+		Method:          "transfer",
+		ContractAddress: "0x5FbDB2315678afecb367f032d93F642f64180aa3",
+		ContractABI:     contractABI,
+		Input:           Arguments{"0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC", "100"},
+		Type:            TxTypeLegacy,
+		From:            from,
+		DryRun:          true,
+	}
+
+	// No signer or private key is needed to simulate
+	resp, err := sendTransaction(ctx, req)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.EqualFold(node.from, from) {
+		t.Errorf("Expected the call to be simulated from %s, got %s", from, node.from)
+	}
+	if resp.Simulation == nil || resp.Simulation.Revert != nil || resp.Nonce != 7 || resp.GasLimit != 60000 {
+		t.Errorf("Expected a successful simulation with nonce 7 and gas limit 60000, got %+v", resp)
+	}
+	if resp.TransactionHash != "" {
+		t.Errorf("Expected no transaction to be sent, got %s", resp.TransactionHash)
+	}
+
+	// Sending still needs a signer
+	req.DryRun = false
+	if _, err := sendTransaction(ctx, req); err == nil {
+		t.Errorf("Expected error for sending from an address without a signer")
+	}
+}
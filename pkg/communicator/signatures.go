@@ -0,0 +1,471 @@
+package communicator
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	SignatureSourceSeed     = "seed"     // Bundled with the binary
+	SignatureSourceImported = "imported" // Imported through the API
+	SignatureSourceABI      = "abi"      // Taken from a registered ABI or artifact
+)
+
+//go:embed signatures/seed.txt
+var seedSignatures []byte
+
+var (
+	registeredSignatureDatabaseMu sync.RWMutex
+	registeredSignatureDatabase   *SignatureDatabase
+
+	// Used until a persisted database is registered, so decoding works without any configuration
+	defaultSignatureDatabase = sync.OnceValue(func() *SignatureDatabase {
+		return newSignatureDatabase("")
+	})
+)
+
+// SignatureDatabase maps 4-byte selectors and event topics to the text signatures which hash to them.
+// Selectors collide, so every candidate is kept and decoding picks the first one fitting the data.
+type SignatureDatabase struct {
+	path string
+
+	mu         sync.RWMutex
+	signatures map[string]string // signature -> source
+	bySelector map[[4]byte][]string
+	byTopic    map[common.Hash][]string
+}
+
+type LookupSignatureRequest struct {
+	Hash string `json:"hash"` // 4-byte selector or 32-byte event topic
+}
+
+type LookupSignatureResponse struct {
+	Signatures []SignatureMatch `json:"signatures"`
+}
+
+type SignatureMatch struct {
+	Signature string `json:"signature"`
+	Selector  string `json:"selector"`
+	Topic     string `json:"topic"`
+	Source    string `json:"source"`
+}
+
+type ImportSignaturesRequest struct {
+	Signatures  []string `json:"signatures"`
+	ContractABI string   `json:"contract_abi"`
+}
+
+type ImportSignaturesResponse struct {
+	Imported int `json:"imported"`
+}
+
+// OpenSignatureDatabase loads the bundled signatures and the ones imported into the file earlier.
+func OpenSignatureDatabase(path string) (*SignatureDatabase, error) {
+	database := newSignatureDatabase(path)
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return database, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open signature database: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, _, err := parseSignature(line); err != nil {
+			slog.Info("Skipping invalid signature", slog.Any("signature", line), slog.Any("err", err))
+			continue
+		}
+		database.add(line, SignatureSourceImported)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read signature database: %v", err)
+	}
+
+	return database, nil
+}
+
+func newSignatureDatabase(path string) *SignatureDatabase {
+	database := &SignatureDatabase{
+		path:       path,
+		signatures: make(map[string]string),
+		bySelector: make(map[[4]byte][]string),
+		byTopic:    make(map[common.Hash][]string),
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(seedSignatures))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		database.add(line, SignatureSourceSeed)
+	}
+	return database
+}
+
+// SetSignatureDatabase registers the signature database used for decoding without ABI.
+func SetSignatureDatabase(database *SignatureDatabase) {
+	registeredSignatureDatabaseMu.Lock()
+	defer registeredSignatureDatabaseMu.Unlock()
+	registeredSignatureDatabase = database
+}
+
+func getSignatureDatabase() *SignatureDatabase {
+	registeredSignatureDatabaseMu.RLock()
+	defer registeredSignatureDatabaseMu.RUnlock()
+	if registeredSignatureDatabase == nil {
+		return defaultSignatureDatabase()
+	}
+	return registeredSignatureDatabase
+}
+
+func LookupSignature(ctx context.Context, req LookupSignatureRequest) (LookupSignatureResponse, error) {
+	return lookupSignature(ctx, req)
+}
+
+func lookupSignature(_ context.Context, req LookupSignatureRequest) (LookupSignatureResponse, error) {
+	hash, err := hexutil.Decode(req.Hash)
+	if err != nil {
+		return LookupSignatureResponse{}, fmt.Errorf("invalid hash %q: %v", req.Hash, err)
+	}
+
+	database := getSignatureDatabase()
+	var signatures []string
+	switch len(hash) {
+	case 4:
+		signatures = database.functions([4]byte(hash))
+	case common.HashLength:
+		signatures = database.events(common.BytesToHash(hash))
+	default:
+		return LookupSignatureResponse{}, fmt.Errorf("expected a 4-byte selector or a 32-byte topic, got %d bytes", len(hash))
+	}
+
+	response := LookupSignatureResponse{
+		Signatures: make([]SignatureMatch, 0, len(signatures)),
+	}
+	for _, signature := range signatures {
+		topic := crypto.Keccak256Hash([]byte(signature))
+		response.Signatures = append(response.Signatures, SignatureMatch{
+			Signature: signature,
+			Selector:  hexutil.Encode(topic[:4]),
+			Topic:     topic.Hex(),
+			Source:    database.source(signature),
+		})
+	}
+
+	return response, nil
+}
+
+func ImportSignatures(ctx context.Context, req ImportSignaturesRequest) (ImportSignaturesResponse, error) {
+	return importSignatures(ctx, req)
+}
+
+func importSignatures(ctx context.Context, req ImportSignaturesRequest) (ImportSignaturesResponse, error) {
+	signatures := make([]string, 0, len(req.Signatures))
+	for _, signature := range req.Signatures {
+		signature = strings.ReplaceAll(strings.TrimSpace(signature), " ", "")
+		if signature == "" {
+			continue
+		}
+		if _, _, err := parseSignature(signature); err != nil {
+			return ImportSignaturesResponse{}, fmt.Errorf("invalid signature %q: %v", signature, err)
+		}
+		signatures = append(signatures, signature)
+	}
+	if req.ContractABI != "" {
+		parsedABI, err := abi.JSON(strings.NewReader(req.ContractABI))
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to parse contract ABI", slog.Any("err", err))
+			return ImportSignaturesResponse{}, fmt.Errorf("failed to parse ABI: %v", err)
+		}
+		signatures = append(signatures, abiSignatures(parsedABI)...)
+	}
+
+	imported, err := getSignatureDatabase().importSignatures(signatures)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to import signatures", slog.Any("err", err))
+		return ImportSignaturesResponse{}, err
+	}
+
+	return ImportSignaturesResponse{Imported: imported}, nil
+}
+
+// addABI indexes the functions, events and errors of a registered ABI, they aren't persisted as the
+// registries load them again on start.
+func (d *SignatureDatabase) addABI(parsedABI abi.ABI) {
+	for _, signature := range abiSignatures(parsedABI) {
+		d.add(signature, SignatureSourceABI)
+	}
+}
+
+// importSignatures appends the new signatures to the file of the database and adds them once they're written.
+func (d *SignatureDatabase) importSignatures(signatures []string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var added []string
+	seen := make(map[string]struct{})
+	for _, signature := range signatures {
+		if source, ok := d.signatures[signature]; ok && source != SignatureSourceABI {
+			continue
+		}
+		if _, ok := seen[signature]; ok {
+			continue
+		}
+		seen[signature] = struct{}{}
+		added = append(added, signature)
+	}
+
+	if d.path != "" && len(added) > 0 {
+		file, err := os.OpenFile(d.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			return 0, fmt.Errorf("failed to open signature database: %v", err)
+		}
+		defer file.Close()
+		if _, err := file.WriteString(strings.Join(added, "\n") + "\n"); err != nil {
+			return 0, fmt.Errorf("failed to write signature database: %v", err)
+		}
+	}
+
+	for _, signature := range added {
+		d.addLocked(signature, SignatureSourceImported)
+	}
+	return len(added), nil
+}
+
+func (d *SignatureDatabase) add(signature, source string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.addLocked(signature, source)
+}
+
+func (d *SignatureDatabase) addLocked(signature, source string) {
+	if _, ok := d.signatures[signature]; !ok {
+		hash := crypto.Keccak256Hash([]byte(signature))
+		selector := [4]byte(hash[:4])
+		d.bySelector[selector] = append(d.bySelector[selector], signature)
+		d.byTopic[hash] = append(d.byTopic[hash], signature)
+	}
+	d.signatures[signature] = source
+}
+
+func (d *SignatureDatabase) source(signature string) string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.signatures[signature]
+}
+
+func (d *SignatureDatabase) functions(selector [4]byte) []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return sortedSignatures(d.bySelector[selector])
+}
+
+func (d *SignatureDatabase) events(topic common.Hash) []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return sortedSignatures(d.byTopic[topic])
+}
+
+func sortedSignatures(signatures []string) []string {
+	sorted := append([]string(nil), signatures...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+func abiSignatures(parsedABI abi.ABI) []string {
+	var signatures []string
+	for _, method := range parsedABI.Methods {
+		signatures = append(signatures, method.Sig)
+	}
+	for _, event := range parsedABI.Events {
+		signatures = append(signatures, event.Sig)
+	}
+	for _, abiError := range parsedABI.Errors {
+		signatures = append(signatures, abiError.Sig)
+	}
+	sort.Strings(signatures)
+	return signatures
+}
+
+// inferCallData decodes the call data with the first candidate signature which decodes and re-encodes
+// to the same data.
+func inferCallData(ctx context.Context, data []byte) (abi.Method, map[string]interface{}, bool) {
+	if len(data) < 4 {
+		return abi.Method{}, nil, false
+	}
+	for _, signature := range getSignatureDatabase().functions([4]byte(data[:4])) {
+		name, args, err := parseSignature(signature)
+		if err != nil {
+			continue
+		}
+		values, err := args.Unpack(data[4:])
+		if err != nil {
+			continue
+		}
+		packed, err := args.Pack(values...)
+		if err != nil || !bytes.Equal(packed, data[4:]) {
+			continue
+		}
+		method := abi.NewMethod(name, name, abi.Function, "", false, false, args, nil)
+		slog.DebugContext(ctx, "Inferred call data signature", slog.Any("signature", signature))
+		return method, argumentsToJSON(args, values, "input"), true
+	}
+	return abi.Method{}, nil, false
+}
+
+// inferLog decodes the log with the candidate event signatures of its first topic. Which arguments are
+// indexed isn't part of the signature, the leading ones are taken as indexed as usual.
+func inferLog(ctx context.Context, log *types.Log) (DecodedLog, bool) {
+	if len(log.Topics) == 0 {
+		return DecodedLog{}, false
+	}
+	for _, signature := range getSignatureDatabase().events(log.Topics[0]) {
+		name, args, err := parseSignature(signature)
+		if err != nil || len(args) < len(log.Topics)-1 {
+			continue
+		}
+		for i := range args {
+			args[i].Name = ""
+			args[i].Indexed = i < len(log.Topics)-1
+		}
+		event := abi.NewEvent(name, name, false, args)
+
+		nonIndexed := event.Inputs.NonIndexed()
+		values, err := nonIndexed.Unpack(log.Data)
+		if err != nil {
+			continue
+		}
+		packed, err := nonIndexed.Pack(values...)
+		if err != nil || !bytes.Equal(packed, log.Data) {
+			continue
+		}
+
+		decoded := decodeLog(ctx, &abi.ABI{Events: map[string]abi.Event{name: event}}, log)
+		if decoded.Error != "" {
+			continue
+		}
+		decoded.Inferred = true
+		return decoded, true
+	}
+	return DecodedLog{}, false
+}
+
+// parseSignature parses a text signature like transfer(address,uint256) into its name and arguments.
+func parseSignature(signature string) (string, abi.Arguments, error) {
+	signature = strings.ReplaceAll(strings.TrimSpace(signature), " ", "")
+	start := strings.Index(signature, "(")
+	if start <= 0 || !strings.HasSuffix(signature, ")") {
+		return "", nil, fmt.Errorf("expected name(types)")
+	}
+	name := signature[:start]
+	for i, c := range name {
+		if !(c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9')) {
+			return "", nil, fmt.Errorf("invalid name %q", name)
+		}
+	}
+
+	typeNames, err := splitSignatureTypes(signature[start+1 : len(signature)-1])
+	if err != nil {
+		return "", nil, err
+	}
+	args := make(abi.Arguments, 0, len(typeNames))
+	for _, typeName := range typeNames {
+		marshaling, err := signatureTypeMarshaling(typeName)
+		if err != nil {
+			return "", nil, err
+		}
+		t, err := abi.NewType(marshaling.Type, "", marshaling.Components)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid type %q: %v", typeName, err)
+		}
+		args = append(args, abi.Argument{Type: t})
+	}
+
+	return name, args, nil
+}
+
+// signatureTypeMarshaling converts a canonical type, where tuples are written as (type,type)[], into the
+// form abi.NewType expects. Tuple components get positional names as the signature has none.
+func signatureTypeMarshaling(typeName string) (abi.ArgumentMarshaling, error) {
+	if !strings.HasPrefix(typeName, "(") {
+		return abi.ArgumentMarshaling{Type: typeName}, nil
+	}
+
+	end := strings.LastIndex(typeName, ")")
+	componentNames, err := splitSignatureTypes(typeName[1:end])
+	if err != nil {
+		return abi.ArgumentMarshaling{}, err
+	}
+	marshaling := abi.ArgumentMarshaling{Type: "tuple" + typeName[end+1:]}
+	for i, componentName := range componentNames {
+		component, err := signatureTypeMarshaling(componentName)
+		if err != nil {
+			return abi.ArgumentMarshaling{}, err
+		}
+		component.Name = fmt.Sprintf("field%d", i)
+		marshaling.Components = append(marshaling.Components, component)
+	}
+	return marshaling, nil
+}
+
+// splitSignatureTypes splits a comma separated type list, ignoring the commas inside of tuples.
+func splitSignatureTypes(types string) ([]string, error) {
+	if types == "" {
+		return nil, nil
+	}
+
+	var (
+		split []string
+		depth int
+		start int
+	)
+	for i, c := range types {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses in %q", types)
+			}
+		case ',':
+			if depth == 0 {
+				split = append(split, types[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses in %q", types)
+	}
+	split = append(split, types[start:])
+	for _, typeName := range split {
+		if typeName == "" {
+			return nil, fmt.Errorf("empty type in %q", types)
+		}
+	}
+
+	return split, nil
+}
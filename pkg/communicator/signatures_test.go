@@ -0,0 +1,134 @@
+package communicator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestInferCallData(t *testing.T) {
+	// transfer(address,uint256) is in the bundled signatures
+	resp, err := DecodeContractCallData(context.Background(), DecodeContractCallDataRequest{
+		InputData: "0xa9059cbb0000000000000000000000002857d75d6f42052ee415396ef1989c96b0768c7c00000000000000000000000000000000000000000000000000000000447bd088",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !resp.Inferred || resp.Signature != "transfer(address,uint256)" {
+		t.Fatalf("Unexpected inferred call %v", resp)
+	}
+	if resp.Args["input_0"] != "0x2857D75d6F42052Ee415396ef1989C96B0768C7C" || resp.Args["input_1"] != "1148965000" {
+		t.Errorf("Unexpected args %v", resp.Args)
+	}
+
+	if _, err := DecodeContractCallData(context.Background(), DecodeContractCallDataRequest{InputData: "0xdeadbeef"}); err == nil {
+		t.Errorf("Expected unknown selector error")
+	}
+}
+
+func TestInferLog(t *testing.T) {
+	// ERC20 Transfer with two indexed addresses
+	log := &types.Log{
+		Address: common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3"),
+		Topics: []common.Hash{
+			common.HexToHash("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"),
+			common.HexToHash("0x000000000000000000000000f39fd6e51aad88f6f4ce6ab8827279cfffb92266"),
+			common.HexToHash("0x0000000000000000000000002857d75d6f42052ee415396ef1989c96b0768c7c"),
+		},
+		Data: common.FromHex("0x00000000000000000000000000000000000000000000000000000000447bd088"),
+	}
+	decoded, ok := inferLog(context.Background(), log)
+	if !ok {
+		t.Fatalf("Expected inferred log")
+	}
+	if decoded.EventName != "Transfer" || !decoded.Inferred {
+		t.Errorf("Unexpected decoded log %v", decoded)
+	}
+	if decoded.Indexed["arg1"] != "0x2857D75d6F42052Ee415396ef1989C96B0768C7C" || decoded.Data["arg2"] != "1148965000" {
+		t.Errorf("Unexpected arguments %v %v", decoded.Indexed, decoded.Data)
+	}
+}
+
+func TestSignatureDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signatures.txt")
+	database, err := OpenSignatureDatabase(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	SetSignatureDatabase(database)
+	t.Cleanup(func() {
+		SetSignatureDatabase(nil)
+	})
+
+	imported, err := ImportSignatures(context.Background(), ImportSignaturesRequest{
+		Signatures: []string{"transfer(address,uint256)", "settle((address,uint256)[], bytes32)"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if imported.Imported != 1 {
+		t.Errorf("Expected only the new signature to be imported, got %d", imported.Imported)
+	}
+	if _, err := ImportSignatures(context.Background(), ImportSignaturesRequest{Signatures: []string{"broken(uint256"}}); err == nil {
+		t.Errorf("Expected invalid signature error")
+	}
+
+	// Imported signatures survive a restart
+	database, err = OpenSignatureDatabase(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	SetSignatureDatabase(database)
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "settle((address,uint256)[],bytes32)\n" {
+		t.Errorf("Unexpected database file %q: %v", data, err)
+	}
+
+	resp, err := LookupSignature(context.Background(), LookupSignatureRequest{Hash: "0xa9059cbb"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(resp.Signatures) != 1 || resp.Signatures[0].Signature != "transfer(address,uint256)" || resp.Signatures[0].Source != SignatureSourceSeed {
+		t.Errorf("Unexpected lookup result %v", resp.Signatures)
+	}
+	resp, err = LookupSignature(context.Background(), LookupSignatureRequest{
+		Hash: "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(resp.Signatures) != 1 || resp.Signatures[0].Signature != "Transfer(address,address,uint256)" {
+		t.Errorf("Unexpected lookup result %v", resp.Signatures)
+	}
+}
+
+func TestSignatureDatabaseFailedImport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signatures.txt")
+	database, err := OpenSignatureDatabase(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	// The file can't be opened for appending anymore
+	if err := os.Mkdir(path, 0o755); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	signature := "settle((address,uint256)[],bytes32)"
+	if _, err := database.importSignatures([]string{signature}); err == nil {
+		t.Fatalf("Expected error for unwritable database")
+	}
+	if source := database.source(signature); source != "" {
+		t.Errorf("Expected signature not to be added, got source %q", source)
+	}
+
+	// Signatures repeated in a request are written once
+	database = newSignatureDatabase(filepath.Join(t.TempDir(), "signatures.txt"))
+	imported, err := database.importSignatures([]string{signature, signature})
+	if err != nil || imported != 1 {
+		t.Errorf("Expected one imported signature, got %d (%v)", imported, err)
+	}
+}
@@ -0,0 +1,398 @@
+package communicator
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// Mnemonic of the default Hardhat and Anvil accounts
+	DefaultMnemonic         = "test test test test test test test test test test test junk"
+	DefaultDerivationPath   = "m/44'/60'/0'/0"
+	DefaultMnemonicAccounts = 10
+
+	SignerTypeMnemonic = "mnemonic"
+	SignerTypeKeystore = "keystore"
+	SignerTypeNode     = "node"
+
+	// Sent with eth_sendTransaction from an account impersonated on a dev node
+	SignerTypeImpersonated = "impersonated"
+
+	nodeSignerPrefix = "node_"
+)
+
+var (
+	registeredSignerRegistryMu sync.RWMutex
+	registeredSignerRegistry   *SignerRegistry
+
+	errNoSigner = errors.New("signer is required")
+)
+
+// SignerConfig describes where the accounts of the SignerRegistry come from.
+type SignerConfig struct {
+	Mnemonic       string
+	Passphrase     string // BIP-39 passphrase of the mnemonic
+	DerivationPath string // the account index is appended to it
+	Accounts       int
+
+	// Keystore file or a directory of keystore files, all of them encrypted with the password
+	KeystorePath     string
+	KeystorePassword string
+}
+
+// SignerRegistry holds the accounts transactions are signed with, so the private keys stay in the backend.
+// Accounts managed by the node (eth_accounts) are resolved per request as the node address can change.
+type SignerRegistry struct {
+	mu      sync.RWMutex
+	signers []*signer
+}
+
+type signer struct {
+	name    string
+	kind    string
+	path    string
+	address common.Address
+	key     *ecdsa.PrivateKey // nil for node accounts, those are sent with eth_sendTransaction
+}
+
+type Signer struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Address string `json:"address"`
+	Path    string `json:"path,omitempty"` // derivation path or keystore file
+}
+
+type GetSignersResponse struct {
+	Signers []Signer `json:"signers"`
+}
+
+// LoadSigners derives the mnemonic accounts and decrypts the keystore files of the config.
+func LoadSigners(config SignerConfig) (*SignerRegistry, error) {
+	registry := &SignerRegistry{}
+
+	if config.Mnemonic != "" {
+		derivationPath := config.DerivationPath
+		if derivationPath == "" {
+			derivationPath = DefaultDerivationPath
+		}
+		count := config.Accounts
+		if count <= 0 {
+			count = DefaultMnemonicAccounts
+		}
+		seed := mnemonicSeed(config.Mnemonic, config.Passphrase)
+		for i := 0; i < count; i++ {
+			path := fmt.Sprintf("%s/%d", strings.TrimSuffix(derivationPath, "/"), i)
+			key, err := deriveMnemonicKey(seed, path)
+			if err != nil {
+				return nil, err
+			}
+			registry.signers = append(registry.signers, &signer{
+				name:    fmt.Sprintf("account_%d", i),
+				kind:    SignerTypeMnemonic,
+				path:    path,
+				address: crypto.PubkeyToAddress(key.PublicKey),
+				key:     key,
+			})
+		}
+	}
+
+	if config.KeystorePath != "" {
+		signers, err := loadKeystores(config.KeystorePath, config.KeystorePassword)
+		if err != nil {
+			return nil, err
+		}
+		registry.signers = append(registry.signers, signers...)
+	}
+
+	return registry, nil
+}
+
+func SetSignerRegistry(registry *SignerRegistry) {
+	registeredSignerRegistryMu.Lock()
+	defer registeredSignerRegistryMu.Unlock()
+	registeredSignerRegistry = registry
+}
+
+func getSignerRegistry() *SignerRegistry {
+	registeredSignerRegistryMu.RLock()
+	defer registeredSignerRegistryMu.RUnlock()
+	return registeredSignerRegistry
+}
+
+func GetSigners(ctx context.Context) (GetSignersResponse, error) {
+	return getSigners(ctx)
+}
+
+func getSigners(ctx context.Context) (GetSignersResponse, error) {
+	response := GetSignersResponse{Signers: []Signer{}}
+	if registry := getSignerRegistry(); registry != nil {
+		registry.mu.RLock()
+		for _, s := range registry.signers {
+			response.Signers = append(response.Signers, s.toSigner())
+		}
+		registry.mu.RUnlock()
+	}
+
+	// Node accounts are optional, nodes without eth_accounts just have none
+	client, err := ethclient.DialContext(ctx, GetNodeAddress(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to connect to Ethereum client", slog.Any("err", err))
+		return GetSignersResponse{}, err
+	}
+	defer client.Close()
+	nodeSigners, err := getNodeSigners(ctx, client)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to get node accounts", slog.Any("err", err))
+		return response, nil
+	}
+	for _, s := range nodeSigners {
+		response.Signers = append(response.Signers, s.toSigner())
+	}
+
+	return response, nil
+}
+
+func (s *signer) toSigner() Signer {
+	return Signer{
+		Name:    s.name,
+		Type:    s.kind,
+		Address: s.address.Hex(),
+		Path:    s.path,
+	}
+}
+
+// resolveSigner finds the signer by name or address among the registered and the node accounts.
+// A raw private key is still accepted for clients which don't use the registry.
+func resolveSigner(ctx context.Context, client *ethclient.Client, ref, privateKeyHex string) (*signer, error) {
+	if ref == "" {
+		if privateKeyHex == "" {
+			return nil, errNoSigner
+		}
+		key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to convert private key from hex", slog.Any("err", err))
+			return nil, fmt.Errorf("failed to convert private key from hex: %v", err)
+		}
+		return &signer{
+			address: crypto.PubkeyToAddress(key.PublicKey),
+			key:     key,
+		}, nil
+	}
+
+	if registry := getSignerRegistry(); registry != nil {
+		if s, ok := registry.signer(ref); ok {
+			return s, nil
+		}
+	}
+
+	nodeSigners, err := getNodeSigners(ctx, client)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get node accounts", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to get node accounts: %v", err)
+	}
+	for _, s := range nodeSigners {
+		if s.matches(ref) {
+			return s, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown signer %q", ref)
+}
+
+func (r *SignerRegistry) signer(ref string) (*signer, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, s := range r.signers {
+		if s.matches(ref) {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+func (s *signer) matches(ref string) bool {
+	if common.IsHexAddress(ref) {
+		return common.HexToAddress(ref) == s.address
+	}
+	return strings.EqualFold(ref, s.name)
+}
+
+func getNodeSigners(ctx context.Context, client *ethclient.Client) ([]*signer, error) {
+	var addresses []common.Address
+	if err := client.Client().CallContext(ctx, &addresses, "eth_accounts"); err != nil {
+		return nil, err
+	}
+
+	signers := make([]*signer, 0, len(addresses))
+	for i, address := range addresses {
+		signers = append(signers, &signer{
+			name:    fmt.Sprintf("%s%d", nodeSignerPrefix, i),
+			kind:    SignerTypeNode,
+			address: address,
+		})
+	}
+	return signers, nil
+}
+
+// sendTransaction signs the transaction locally or lets the node sign it for node accounts.
+func (s *signer) sendTransaction(ctx context.Context, client *ethclient.Client, chainID *big.Int, txData types.TxData) (common.Hash, error) {
+	if s.key == nil {
+		return sendNodeTransaction(ctx, client, s.address, types.NewTx(txData))
+	}
+
+	signedTx, err := types.SignNewTx(s.key, types.LatestSignerForChainID(chainID), txData)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to sign transaction", slog.Any("err", err))
+		return common.Hash{}, fmt.Errorf("failed to sign transaction: %v", err)
+	}
+	if err := client.SendTransaction(ctx, signedTx); err != nil {
+		slog.ErrorContext(ctx, "Failed to send transaction", slog.Any("err", err))
+		return common.Hash{}, fmt.Errorf("failed to send transaction: %v", err)
+	}
+	return signedTx.Hash(), nil
+}
+
+// sendNodeTransaction sends the unsigned transaction with eth_sendTransaction from an account of the node.
+func sendNodeTransaction(ctx context.Context, client *ethclient.Client, from common.Address, tx *types.Transaction) (common.Hash, error) {
+	args := map[string]interface{}{
+		"from":  from,
+		"to":    tx.To(),
+		"gas":   hexutil.Uint64(tx.Gas()),
+		"value": (*hexutil.Big)(tx.Value()),
+		"data":  hexutil.Bytes(tx.Data()),
+		"nonce": hexutil.Uint64(tx.Nonce()),
+	}
+	switch tx.Type() {
+	case types.DynamicFeeTxType:
+		args["maxFeePerGas"] = (*hexutil.Big)(tx.GasFeeCap())
+		args["maxPriorityFeePerGas"] = (*hexutil.Big)(tx.GasTipCap())
+	default:
+		args["gasPrice"] = (*hexutil.Big)(tx.GasPrice())
+	}
+	if tx.Type() != types.LegacyTxType {
+		args["type"] = hexutil.Uint64(tx.Type())
+		args["accessList"] = tx.AccessList()
+	}
+
+	var hash common.Hash
+	if err := client.Client().CallContext(ctx, &hash, "eth_sendTransaction", args); err != nil {
+		slog.ErrorContext(ctx, "Failed to send transaction", slog.Any("from", from.Hex()), slog.Any("err", err))
+		return common.Hash{}, fmt.Errorf("failed to send transaction: %v", err)
+	}
+	return hash, nil
+}
+
+func loadKeystores(path, password string) ([]*signer, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore: %v", err)
+	}
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keystore directory: %v", err)
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+				continue
+			}
+			files = append(files, filepath.Join(path, entry.Name()))
+		}
+		sort.Strings(files)
+	}
+
+	signers := make([]*signer, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keystore file %s: %v", file, err)
+		}
+		key, err := keystore.DecryptKey(data, password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt keystore file %s: %v", file, err)
+		}
+		signers = append(signers, &signer{
+			name:    strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)),
+			kind:    SignerTypeKeystore,
+			path:    file,
+			address: key.Address,
+			key:     key.PrivateKey,
+		})
+	}
+	return signers, nil
+}
+
+// mnemonicSeed derives the BIP-39 seed, the checksum of the mnemonic isn't verified.
+func mnemonicSeed(mnemonic, passphrase string) []byte {
+	normalized := strings.Join(strings.Fields(mnemonic), " ")
+	return pbkdf2.Key([]byte(normalized), []byte("mnemonic"+passphrase), 2048, 64, sha512.New)
+}
+
+// deriveMnemonicKey derives the BIP-32 private key of the path from the seed.
+func deriveMnemonicKey(seed []byte, path string) (*ecdsa.PrivateKey, error) {
+	derivationPath, err := accounts.ParseDerivationPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid derivation path %q: %v", path, err)
+	}
+
+	n := crypto.S256().Params().N
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := new(big.Int).SetBytes(sum[:32]), sum[32:]
+	if key.Sign() == 0 || key.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("invalid master key")
+	}
+
+	for _, index := range derivationPath {
+		var data []byte
+		if index >= 0x80000000 {
+			data = append([]byte{0}, common.LeftPadBytes(key.Bytes(), 32)...)
+		} else {
+			parent, err := crypto.ToECDSA(common.LeftPadBytes(key.Bytes(), 32))
+			if err != nil {
+				return nil, err
+			}
+			data = crypto.CompressPubkey(&parent.PublicKey)
+		}
+		data = append(data, byte(index>>24), byte(index>>16), byte(index>>8), byte(index))
+
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+		tweak := new(big.Int).SetBytes(sum[:32])
+		if tweak.Cmp(n) >= 0 {
+			return nil, fmt.Errorf("invalid child key at %s", path)
+		}
+		key = tweak.Add(tweak, key)
+		key.Mod(key, n)
+		if key.Sign() == 0 {
+			return nil, fmt.Errorf("invalid child key at %s", path)
+		}
+		chainCode = sum[32:]
+	}
+
+	return crypto.ToECDSA(common.LeftPadBytes(key.Bytes(), 32))
+}
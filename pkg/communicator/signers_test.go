@@ -0,0 +1,79 @@
+package communicator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestLoadSignersMnemonic(t *testing.T) {
+	registry, err := LoadSigners(SignerConfig{Mnemonic: DefaultMnemonic, Accounts: 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(registry.signers) != 2 {
+		t.Fatalf("Expected 2 signers, got %d", len(registry.signers))
+	}
+
+	// Well-known Hardhat and Anvil accounts
+	first := registry.signers[0]
+	if first.address != common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266") {
+		t.Errorf("Expected first Hardhat account, got %s", first.address.Hex())
+	}
+	if key := common.Bytes2Hex(crypto.FromECDSA(first.key)); key != "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80" {
+		t.Errorf("Expected first Hardhat private key, got %s", key)
+	}
+	if first.path != "m/44'/60'/0'/0/0" {
+		t.Errorf("Expected derivation path m/44'/60'/0'/0/0, got %s", first.path)
+	}
+
+	s, ok := registry.signer("ACCOUNT_1")
+	if !ok || s.address != common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8") {
+		t.Errorf("Expected second Hardhat account by name, got %v", s)
+	}
+	if _, ok := registry.signer("0x70997970c51812dc3a010c7d01b50e0d17dc79c8"); !ok {
+		t.Errorf("Expected signer by lowercase address")
+	}
+	if _, ok := registry.signer("account_2"); ok {
+		t.Errorf("Expected no third account")
+	}
+}
+
+func TestLoadSignersKeystore(t *testing.T) {
+	dir := t.TempDir()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	data, err := keystore.EncryptKey(&keystore.Key{
+		Address:    address,
+		PrivateKey: key,
+	}, "secret", keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "deployer.json"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	registry, err := LoadSigners(SignerConfig{KeystorePath: dir, KeystorePassword: "secret"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	s, ok := registry.signer("deployer")
+	if !ok {
+		t.Fatalf("Expected keystore signer named after the file")
+	}
+	if s.address != address || s.kind != SignerTypeKeystore {
+		t.Errorf("Expected keystore signer %s, got %s %s", address.Hex(), s.kind, s.address.Hex())
+	}
+
+	if _, err := LoadSigners(SignerConfig{KeystorePath: dir, KeystorePassword: "wrong"}); err == nil {
+		t.Errorf("Expected error for wrong password")
+	}
+}
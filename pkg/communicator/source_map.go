@@ -0,0 +1,174 @@
+package communicator
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Unlinked library references in the bytecode, e.g. __$1c9a5f1d5e9b7d8d2a4f0c3b6e7a8d9c0b$__
+var libraryPlaceholder = regexp.MustCompile(`__\$[0-9a-fA-F]{34}\$__|__[A-Za-z0-9_.:/$]{36}__`)
+
+// SourceArtifact holds the compiler output needed to map program counters back to the Solidity source.
+type SourceArtifact struct {
+	DeployedBytecode  string `json:"deployed_bytecode"`
+	DeployedSourceMap string `json:"deployed_source_map"`
+
+	// Source files keyed by their solc source ID
+	Sources []SourceFile `json:"sources"`
+}
+
+type SourceFile struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+type SourceLocation struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+
+	// Jump type of the instruction: i (into a function), o (out of a function) or - (regular jump)
+	Jump string `json:"jump,omitempty"`
+}
+
+// sourceMapper resolves the program counters of the deployed bytecode to source locations.
+type sourceMapper struct {
+	instructions map[uint64]int // pc -> instruction index
+	entries      []sourceMapEntry
+	files        map[int]sourceFileLines
+}
+
+type sourceMapEntry struct {
+	offset int
+	length int
+	file   int
+	jump   string
+}
+
+type sourceFileLines struct {
+	name       string
+	lineStarts []int
+}
+
+func newSourceMapper(artifact SourceArtifact) (*sourceMapper, error) {
+	code := libraryPlaceholder.ReplaceAllString(strings.TrimPrefix(artifact.DeployedBytecode, "0x"), strings.Repeat("0", 40))
+	bytecode, err := hexutil.Decode("0x" + code)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deployed bytecode: %v", err)
+	}
+	entries, err := parseSourceMap(artifact.DeployedSourceMap)
+	if err != nil {
+		return nil, err
+	}
+
+	mapper := &sourceMapper{
+		instructions: instructionIndexes(bytecode),
+		entries:      entries,
+		files:        make(map[int]sourceFileLines),
+	}
+	for _, source := range artifact.Sources {
+		lineStarts := []int{0}
+		for i, c := range source.Content {
+			if c == '\n' {
+				lineStarts = append(lineStarts, i+1)
+			}
+		}
+		mapper.files[source.ID] = sourceFileLines{
+			name:       source.Name,
+			lineStarts: lineStarts,
+		}
+	}
+
+	return mapper, nil
+}
+
+// lookup returns the source location of the instruction at pc. Compiler generated code has no location.
+func (m *sourceMapper) lookup(pc uint64) (SourceLocation, bool) {
+	index, ok := m.instructions[pc]
+	if !ok || index >= len(m.entries) {
+		return SourceLocation{}, false
+	}
+	entry := m.entries[index]
+	file, ok := m.files[entry.file]
+	if !ok || entry.offset < 0 {
+		return SourceLocation{}, false
+	}
+
+	line := sort.Search(len(file.lineStarts), func(i int) bool {
+		return file.lineStarts[i] > entry.offset
+	})
+	return SourceLocation{
+		File:   file.name,
+		Line:   line,
+		Column: entry.offset - file.lineStarts[line-1] + 1,
+		Offset: entry.offset,
+		Length: entry.length,
+		Jump:   entry.jump,
+	}, true
+}
+
+// parseSourceMap decodes the compressed solc source map, empty fields repeat the previous entry.
+func parseSourceMap(sourceMap string) ([]sourceMapEntry, error) {
+	if sourceMap == "" {
+		return nil, nil
+	}
+
+	var entries []sourceMapEntry
+	current := sourceMapEntry{file: -1}
+	for i, rawEntry := range strings.Split(sourceMap, ";") {
+		fields := strings.Split(rawEntry, ":")
+		for j, field := range fields {
+			if field == "" {
+				continue
+			}
+			if j == 3 {
+				current.jump = field
+				continue
+			}
+			if j > 3 {
+				// Modifier depth isn't used
+				continue
+			}
+			value, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid source map entry %d: %q", i, rawEntry)
+			}
+			switch j {
+			case 0:
+				current.offset = value
+			case 1:
+				current.length = value
+			case 2:
+				current.file = value
+			}
+		}
+		entries = append(entries, current)
+	}
+
+	return entries, nil
+}
+
+// instructionIndexes maps the pc of every instruction to its index, source maps are indexed by instruction.
+func instructionIndexes(bytecode []byte) map[uint64]int {
+	indexes := make(map[uint64]int)
+	index := 0
+	for pc := 0; pc < len(bytecode); pc++ {
+		indexes[uint64(pc)] = index
+		index++
+
+		// PUSH1..PUSH32 are followed by their immediate value
+		if op := bytecode[pc]; op >= 0x60 && op <= 0x7f {
+			pc += int(op - 0x5f)
+		}
+	}
+
+	return indexes
+}
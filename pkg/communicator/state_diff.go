@@ -0,0 +1,227 @@
+package communicator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type GetStateDiffRequest struct {
+	Hash string `json:"hash"`
+
+	// solc storageLayout outputs keyed by contract address, used to name the storage slots. Layouts
+	// of the contracts missing here are taken from the registries.
+	StorageLayouts map[string]StorageLayout `json:"storage_layouts"`
+}
+
+type GetStateDiffResponse struct {
+	Accounts []AccountDiff `json:"accounts"`
+}
+
+type AccountDiff struct {
+	Address string          `json:"address"`
+	Created bool            `json:"created"`
+	Deleted bool            `json:"deleted"`
+	Balance *ValueChange    `json:"balance,omitempty"`
+	Nonce   *ValueChange    `json:"nonce,omitempty"`
+	Code    *ValueChange    `json:"code,omitempty"`
+	Storage []StorageChange `json:"storage"`
+}
+
+type ValueChange struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+type StorageChange struct {
+	Slot   string `json:"slot"`
+	Label  string `json:"label,omitempty"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// prestateAccount is the account format returned by the prestateTracer
+type prestateAccount struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Nonce   *uint64                     `json:"nonce"`
+	Code    *hexutil.Bytes              `json:"code"`
+	Storage map[common.Hash]common.Hash `json:"storage"`
+}
+
+type prestateDiff struct {
+	Pre  map[common.Address]prestateAccount `json:"pre"`
+	Post map[common.Address]prestateAccount `json:"post"`
+}
+
+func GetStateDiff(ctx context.Context, req GetStateDiffRequest) (GetStateDiffResponse, error) {
+	return getStateDiff(ctx, req)
+}
+
+func getStateDiff(ctx context.Context, req GetStateDiffRequest) (GetStateDiffResponse, error) {
+	rpcClient, err := rpc.DialContext(ctx, GetNodeAddress(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to connect to Ethereum client", slog.Any("err", err))
+		return GetStateDiffResponse{}, err
+	}
+	defer rpcClient.Close()
+	client := ethclient.NewClient(rpcClient)
+
+	hash := common.HexToHash(req.Hash)
+	var diff prestateDiff
+	err = rpcClient.CallContext(ctx, &diff, "debug_traceTransaction", hash, map[string]interface{}{
+		"tracer": "prestateTracer",
+		"tracerConfig": map[string]interface{}{
+			"diffMode": true,
+		},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to trace transaction", slog.Any("hash", req.Hash), slog.Any("err", err))
+		return GetStateDiffResponse{}, fmt.Errorf("failed to trace transaction: %v", err)
+	}
+
+	layouts := make(map[common.Address]StorageLayout)
+	for address, layout := range req.StorageLayouts {
+		layouts[common.HexToAddress(address)] = layout
+	}
+	for _, accounts := range []map[common.Address]prestateAccount{diff.Pre, diff.Post} {
+		for address, account := range accounts {
+			if _, ok := layouts[address]; ok || len(account.Storage) == 0 {
+				continue
+			}
+			if layout := registeredStorageLayout(ctx, address); layout != nil {
+				layouts[address] = *layout
+			}
+		}
+	}
+
+	// Mapping slots are resolved by trying the addresses and call data words of the transaction as keys
+	var candidateKeys []common.Hash
+	if len(layouts) > 0 {
+		transaction, _, err := client.TransactionByHash(ctx, hash)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to get transaction by hash", slog.Any("hash", req.Hash), slog.Any("err", err))
+			return GetStateDiffResponse{}, err
+		}
+		parsedTransaction, err := parseTransaction(transaction, "", 0)
+		if err != nil {
+			return GetStateDiffResponse{}, err
+		}
+		addresses := []common.Address{common.HexToAddress(parsedTransaction.From)}
+		for address := range diff.Pre {
+			addresses = append(addresses, address)
+		}
+		for address := range diff.Post {
+			addresses = append(addresses, address)
+		}
+		candidateKeys = mappingKeyCandidates(addresses, transaction.Data())
+	}
+
+	resolvers := make(map[common.Address]*slotResolver)
+	for address, layout := range layouts {
+		resolvers[address] = newSlotResolver(layout, candidateKeys)
+	}
+
+	return GetStateDiffResponse{
+		Accounts: parseStateDiff(diff, resolvers),
+	}, nil
+}
+
+func parseStateDiff(diff prestateDiff, resolvers map[common.Address]*slotResolver) []AccountDiff {
+	addresses := make(map[common.Address]struct{})
+	for address := range diff.Pre {
+		addresses[address] = struct{}{}
+	}
+	for address := range diff.Post {
+		addresses[address] = struct{}{}
+	}
+
+	accounts := make([]AccountDiff, 0, len(addresses))
+	for address := range addresses {
+		pre, inPre := diff.Pre[address]
+		post, inPost := diff.Post[address]
+		account := AccountDiff{
+			Address: address.Hex(),
+			Created: !inPre && inPost,
+			Deleted: inPre && !inPost,
+			Storage: []StorageChange{},
+		}
+
+		// The post state only holds the changed fields, deleted accounts are missing from it
+		if !account.Deleted {
+			if post.Balance != nil {
+				account.Balance = &ValueChange{Before: prestateBalance(pre), After: post.Balance.ToInt().String()}
+			}
+			if post.Nonce != nil {
+				account.Nonce = &ValueChange{Before: prestateNonce(pre), After: fmt.Sprintf("%d", *post.Nonce)}
+			}
+			if post.Code != nil {
+				account.Code = &ValueChange{Before: prestateCode(pre), After: post.Code.String()}
+			}
+		} else {
+			account.Balance = &ValueChange{Before: prestateBalance(pre), After: "0"}
+			account.Nonce = &ValueChange{Before: prestateNonce(pre), After: "0"}
+			account.Code = &ValueChange{Before: prestateCode(pre), After: "0x"}
+		}
+
+		// Zeroed slots are missing from the post state
+		slots := make(map[common.Hash]struct{})
+		for slot := range pre.Storage {
+			slots[slot] = struct{}{}
+		}
+		for slot := range post.Storage {
+			slots[slot] = struct{}{}
+		}
+		for slot := range slots {
+			before, after := pre.Storage[slot], post.Storage[slot]
+			if before == after {
+				continue
+			}
+			change := StorageChange{
+				Slot:   slot.Hex(),
+				Before: before.Hex(),
+				After:  after.Hex(),
+			}
+			if resolver, ok := resolvers[address]; ok {
+				change.Label = resolver.resolve(slot)
+			}
+			account.Storage = append(account.Storage, change)
+		}
+		sort.Slice(account.Storage, func(i, j int) bool {
+			return account.Storage[i].Slot < account.Storage[j].Slot
+		})
+
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Address < accounts[j].Address
+	})
+
+	return accounts
+}
+
+func prestateBalance(account prestateAccount) string {
+	if account.Balance == nil {
+		return "0"
+	}
+	return account.Balance.ToInt().String()
+}
+
+func prestateNonce(account prestateAccount) string {
+	if account.Nonce == nil {
+		return "0"
+	}
+	return fmt.Sprintf("%d", *account.Nonce)
+}
+
+func prestateCode(account prestateAccount) string {
+	if account.Code == nil {
+		return "0x"
+	}
+	return account.Code.String()
+}
@@ -0,0 +1,73 @@
+package communicator
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// solc storageLayout of an OpenZeppelin style ERC20 with an extra holders array for testing
+	erc20StorageLayout = `{"storage":[{"label":"_balances","offset":0,"slot":"0","type":"t_mapping(t_address,t_uint256)"},{"label":"_allowances","offset":0,"slot":"1","type":"t_mapping(t_address,t_mapping(t_address,t_uint256))"},{"label":"_totalSupply","offset":0,"slot":"2","type":"t_uint256"},{"label":"_name","offset":0,"slot":"3","type":"t_string_storage"},{"label":"_holders","offset":0,"slot":"4","type":"t_array(t_address)dyn_storage"}],"types":{"t_address":{"encoding":"inplace","label":"address","numberOfBytes":"20"},"t_array(t_address)dyn_storage":{"base":"t_address","encoding":"dynamic_array","label":"address[]","numberOfBytes":"32"},"t_mapping(t_address,t_mapping(t_address,t_uint256))":{"encoding":"mapping","key":"t_address","label":"mapping(address => mapping(address => uint256))","numberOfBytes":"32","value":"t_mapping(t_address,t_uint256)"},"t_mapping(t_address,t_uint256)":{"encoding":"mapping","key":"t_address","label":"mapping(address => uint256)","numberOfBytes":"32","value":"t_uint256"},"t_string_storage":{"encoding":"bytes","label":"string","numberOfBytes":"32"},"t_uint256":{"encoding":"inplace","label":"uint256","numberOfBytes":"32"}}}`
+)
+
+func TestParseStateDiff(t *testing.T) {
+	var layout StorageLayout
+	if err := json.Unmarshal([]byte(erc20StorageLayout), &layout); err != nil {
+		t.Fatalf("Failed to unmarshal storage layout: %v", err)
+	}
+
+	token := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+	owner := common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+	spender := common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8")
+
+	balanceSlot := crypto.Keccak256Hash(common.BytesToHash(owner.Bytes()).Bytes(), common.BigToHash(big.NewInt(0)).Bytes())
+	allowanceSlot := crypto.Keccak256Hash(
+		common.BytesToHash(spender.Bytes()).Bytes(),
+		crypto.Keccak256Hash(common.BytesToHash(owner.Bytes()).Bytes(), common.BigToHash(big.NewInt(1)).Bytes()).Bytes(),
+	)
+	holderSlot := common.BigToHash(new(big.Int).Add(crypto.Keccak256Hash(common.BigToHash(big.NewInt(4)).Bytes()).Big(), big.NewInt(2)))
+
+	diff := prestateDiff{
+		Pre: map[common.Address]prestateAccount{
+			token: {Storage: map[common.Hash]common.Hash{
+				balanceSlot: common.BigToHash(big.NewInt(100)),
+			}},
+		},
+		Post: map[common.Address]prestateAccount{
+			token: {Storage: map[common.Hash]common.Hash{
+				balanceSlot:                     common.BigToHash(big.NewInt(60)),
+				allowanceSlot:                   common.BigToHash(big.NewInt(40)),
+				common.BigToHash(big.NewInt(2)): common.BigToHash(big.NewInt(1000)),
+				holderSlot:                      common.BytesToHash(spender.Bytes()),
+			}},
+		},
+	}
+
+	resolvers := map[common.Address]*slotResolver{
+		token: newSlotResolver(layout, mappingKeyCandidates([]common.Address{owner, spender, token}, nil)),
+	}
+	accounts := parseStateDiff(diff, resolvers)
+	if len(accounts) != 1 || accounts[0].Created {
+		t.Fatalf("Unexpected accounts %v", accounts)
+	}
+
+	labels := make(map[string]string)
+	for _, change := range accounts[0].Storage {
+		labels[change.Slot] = change.Label
+	}
+	expected := map[common.Hash]string{
+		balanceSlot:                     "_balances[" + owner.Hex() + "]",
+		allowanceSlot:                   "_allowances[" + owner.Hex() + "][" + spender.Hex() + "]",
+		common.BigToHash(big.NewInt(2)): "_totalSupply",
+		holderSlot:                      "_holders[2]",
+	}
+	for slot, label := range expected {
+		if labels[slot.Hex()] != label {
+			t.Errorf("Expected slot %s to be '%s', got '%s'", slot.Hex(), label, labels[slot.Hex()])
+		}
+	}
+}
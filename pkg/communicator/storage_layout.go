@@ -0,0 +1,271 @@
+package communicator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// Static arrays longer than this are only resolved up to this index
+	maxStaticArrayElements = 256
+
+	// Touched slots further than this from the start of a dynamic array aren't treated as its elements
+	maxDynamicArraySlots = 1 << 32
+)
+
+// StorageLayout is the storageLayout output of solc.
+type StorageLayout struct {
+	Storage []StorageLayoutEntry         `json:"storage"`
+	Types   map[string]StorageLayoutType `json:"types"`
+}
+
+type StorageLayoutEntry struct {
+	Label  string `json:"label"`
+	Offset int    `json:"offset"`
+	Slot   string `json:"slot"`
+	Type   string `json:"type"`
+}
+
+type StorageLayoutType struct {
+	Encoding      string               `json:"encoding"` // inplace, mapping, dynamic_array or bytes
+	Label         string               `json:"label"`
+	NumberOfBytes string               `json:"numberOfBytes"`
+	Key           string               `json:"key,omitempty"`
+	Value         string               `json:"value,omitempty"`
+	Base          string               `json:"base,omitempty"`
+	Members       []StorageLayoutEntry `json:"members,omitempty"`
+}
+
+// UnmarshalJSON accepts the storage layout both as a JSON object and as a JSON encoded string.
+func (l *StorageLayout) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		data = []byte(str)
+	}
+
+	type storageLayout StorageLayout
+	var layout storageLayout
+	if err := json.Unmarshal(data, &layout); err != nil {
+		return err
+	}
+	*l = StorageLayout(layout)
+	return nil
+}
+
+// slotResolver maps storage slots back to the variables of a storage layout. Mapping slots can't be
+// inverted, so they are resolved by hashing the candidate keys (e.g. addresses and call data words
+// of the transaction) with the slot of the mapping.
+type slotResolver struct {
+	layout        StorageLayout
+	candidateKeys []common.Hash
+	labels        map[common.Hash][]string
+	dynamicAreas  []dynamicArea
+}
+
+// dynamicArea is the data area of a dynamic array or long bytes/string starting at keccak256(slot)
+type dynamicArea struct {
+	label string
+	start *big.Int
+
+	// Element type of arrays, empty for bytes/string
+	base string
+}
+
+func newSlotResolver(layout StorageLayout, candidateKeys []common.Hash) *slotResolver {
+	resolver := &slotResolver{
+		layout:        layout,
+		candidateKeys: candidateKeys,
+		labels:        make(map[common.Hash][]string),
+	}
+	for _, entry := range layout.Storage {
+		slot, ok := new(big.Int).SetString(entry.Slot, 10)
+		if !ok {
+			continue
+		}
+		resolver.expand(entry.Label, entry.Type, slot, entry.Offset, 0)
+	}
+
+	return resolver
+}
+
+// resolve returns the variable (or variables, if packed) stored in the slot.
+func (r *slotResolver) resolve(slot common.Hash) string {
+	if labels, ok := r.labels[slot]; ok {
+		return strings.Join(labels, ", ")
+	}
+
+	slotInt := slot.Big()
+	for _, area := range r.dynamicAreas {
+		diff := new(big.Int).Sub(slotInt, area.start)
+		if diff.Sign() < 0 || diff.Cmp(big.NewInt(maxDynamicArraySlots)) >= 0 {
+			continue
+		}
+		if area.base == "" {
+			return fmt.Sprintf("%s (data)", area.label)
+		}
+
+		elementSize := r.typeSize(area.base)
+		if elementSize <= 16 {
+			// Packed elements share the slot
+			perSlot := uint64(32 / elementSize)
+			first := diff.Uint64() * perSlot
+			return fmt.Sprintf("%s[%d..%d]", area.label, first, first+perSlot-1)
+		}
+
+		// Resolve the element holding the slot, it may be a struct spanning multiple slots
+		slotsPerElement := uint64((elementSize + 31) / 32)
+		index := diff.Uint64() / slotsPerElement
+		elementSlot := new(big.Int).Add(area.start, new(big.Int).SetUint64(index*slotsPerElement))
+		element := &slotResolver{
+			layout:        r.layout,
+			candidateKeys: r.candidateKeys,
+			labels:        make(map[common.Hash][]string),
+		}
+		element.expand(fmt.Sprintf("%s[%d]", area.label, index), area.base, elementSlot, 0, 0)
+		if label := element.resolve(slot); label != "" {
+			return label
+		}
+	}
+
+	return ""
+}
+
+func (r *slotResolver) expand(label, typeID string, slot *big.Int, offset int, depth int) {
+	layoutType, ok := r.layout.Types[typeID]
+	if !ok || depth > 8 {
+		r.add(slot, label)
+		return
+	}
+
+	switch layoutType.Encoding {
+	case "mapping":
+		r.add(slot, label)
+		for _, key := range r.candidateKeys {
+			valueSlot := crypto.Keccak256Hash(key.Bytes(), common.BigToHash(slot).Bytes()).Big()
+			keyLabel := fmt.Sprintf("%s[%s]", label, formatMappingKey(r.layout.Types[layoutType.Key], key))
+			r.expand(keyLabel, layoutType.Value, valueSlot, 0, depth+1)
+		}
+	case "dynamic_array":
+		r.add(slot, label+".length")
+		r.dynamicAreas = append(r.dynamicAreas, dynamicArea{
+			label: label,
+			start: crypto.Keccak256Hash(common.BigToHash(slot).Bytes()).Big(),
+			base:  layoutType.Base,
+		})
+	case "bytes":
+		r.add(slot, label)
+		r.dynamicAreas = append(r.dynamicAreas, dynamicArea{
+			label: label,
+			start: crypto.Keccak256Hash(common.BigToHash(slot).Bytes()).Big(),
+		})
+	default:
+		switch {
+		case len(layoutType.Members) > 0:
+			for _, member := range layoutType.Members {
+				memberSlot, ok := new(big.Int).SetString(member.Slot, 10)
+				if !ok {
+					continue
+				}
+				r.expand(label+"."+member.Label, member.Type, memberSlot.Add(memberSlot, slot), member.Offset, depth+1)
+			}
+		case layoutType.Base != "":
+			r.expandStaticArray(label, layoutType, slot, depth)
+		default:
+			// Values longer than a slot occupy the following slots as well
+			size := r.typeSize(typeID)
+			for i := 0; i < (offset+size+31)/32; i++ {
+				r.add(new(big.Int).Add(slot, big.NewInt(int64(i))), label)
+			}
+		}
+	}
+}
+
+func (r *slotResolver) expandStaticArray(label string, layoutType StorageLayoutType, slot *big.Int, depth int) {
+	elementSize := r.typeSize(layoutType.Base)
+	if elementSize == 0 {
+		r.add(slot, label)
+		return
+	}
+	totalSize, _ := strconv.Atoi(layoutType.NumberOfBytes)
+
+	if elementSize < 32 {
+		perSlot := 32 / elementSize
+		length := min(totalSize/32*perSlot, maxStaticArrayElements)
+		for i := 0; i < length; i++ {
+			elementSlot := new(big.Int).Add(slot, big.NewInt(int64(i/perSlot)))
+			r.expand(fmt.Sprintf("%s[%d]", label, i), layoutType.Base, elementSlot, (i%perSlot)*elementSize, depth+1)
+		}
+		return
+	}
+
+	slotsPerElement := (elementSize + 31) / 32
+	length := min(totalSize/32/slotsPerElement, maxStaticArrayElements)
+	for i := 0; i < length; i++ {
+		elementSlot := new(big.Int).Add(slot, big.NewInt(int64(i*slotsPerElement)))
+		r.expand(fmt.Sprintf("%s[%d]", label, i), layoutType.Base, elementSlot, 0, depth+1)
+	}
+}
+
+func (r *slotResolver) typeSize(typeID string) int {
+	size, err := strconv.Atoi(r.layout.Types[typeID].NumberOfBytes)
+	if err != nil {
+		return 32
+	}
+	return size
+}
+
+func (r *slotResolver) add(slot *big.Int, label string) {
+	key := common.BigToHash(slot)
+	for _, existing := range r.labels[key] {
+		if existing == label {
+			return
+		}
+	}
+	r.labels[key] = append(r.labels[key], label)
+}
+
+func formatMappingKey(keyType StorageLayoutType, key common.Hash) string {
+	switch {
+	case keyType.Label == "address" || strings.HasPrefix(keyType.Label, "contract "):
+		return common.BytesToAddress(key.Bytes()).Hex()
+	case strings.HasPrefix(keyType.Label, "uint"):
+		return key.Big().String()
+	case keyType.Label == "bool":
+		return strconv.FormatBool(key.Big().Sign() != 0)
+	}
+	return key.Hex()
+}
+
+// mappingKeyCandidates collects the 32 byte words which might have been used as mapping keys.
+func mappingKeyCandidates(addresses []common.Address, callData []byte) []common.Hash {
+	seen := make(map[common.Hash]struct{})
+	var candidates []common.Hash
+	add := func(candidate common.Hash) {
+		if _, ok := seen[candidate]; ok {
+			return
+		}
+		seen[candidate] = struct{}{}
+		candidates = append(candidates, candidate)
+	}
+
+	for _, address := range addresses {
+		add(common.BytesToHash(address.Bytes()))
+	}
+	if len(callData) > 4 {
+		for i := 4; i+32 <= len(callData); i += 32 {
+			add(common.BytesToHash(callData[i : i+32]))
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Cmp(candidates[j]) < 0
+	})
+
+	return candidates
+}
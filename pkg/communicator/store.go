@@ -0,0 +1,218 @@
+package communicator
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/leveldb"
+)
+
+const (
+	storeCache   = 16 // MB
+	storeHandles = 16
+)
+
+var (
+	chainIDKey     = []byte("m:chain_id")
+	genesisHashKey = []byte("m:genesis_hash")
+	headKey        = []byte("m:head")
+
+	blockPrefix       = []byte("b:") // blockPrefix + number (uint64 big endian) -> Block
+	transactionPrefix = []byte("t:") // transactionPrefix + hash -> Transaction
+)
+
+// Store keeps the indexed blocks and transactions in an embedded key-value database.
+type Store struct {
+	db ethdb.KeyValueStore
+}
+
+func NewStore(db ethdb.KeyValueStore) *Store {
+	return &Store{db: db}
+}
+
+// OpenStore opens (or creates) a LevelDB backed store in the given directory.
+func OpenStore(path string) (*Store, error) {
+	db, err := leveldb.New(path, storeCache, storeHandles, "letherscan/store", false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open store: %v", err)
+	}
+	return NewStore(db), nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// ChainInfo returns the chain ID and genesis hash the stored data belongs to.
+func (s *Store) ChainInfo() (string, string, error) {
+	chainID, _, err := s.get(chainIDKey)
+	if err != nil {
+		return "", "", err
+	}
+	genesisHash, _, err := s.get(genesisHashKey)
+	if err != nil {
+		return "", "", err
+	}
+	return string(chainID), string(genesisHash), nil
+}
+
+func (s *Store) SetChainInfo(chainID, genesisHash string) error {
+	batch := s.db.NewBatch()
+	if err := batch.Put(chainIDKey, []byte(chainID)); err != nil {
+		return err
+	}
+	if err := batch.Put(genesisHashKey, []byte(genesisHash)); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+// Head returns the number of the last indexed block.
+func (s *Store) Head() (uint64, bool, error) {
+	head, ok, err := s.get(headKey)
+	if err != nil || !ok {
+		return 0, false, err
+	}
+	return binary.BigEndian.Uint64(head), true, nil
+}
+
+func (s *Store) Block(number uint64) (Block, bool, error) {
+	var block Block
+	ok, err := s.getJSON(blockKey(number), &block)
+	return block, ok, err
+}
+
+func (s *Store) Transaction(hash string) (Transaction, bool, error) {
+	var transaction Transaction
+	ok, err := s.getJSON(transactionKey(hash), &transaction)
+	return transaction, ok, err
+}
+
+// WriteBlock stores the block with its transactions and moves the head to it.
+func (s *Store) WriteBlock(block Block) error {
+	number, err := strconv.ParseUint(block.Header.Number, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid block number %q: %v", block.Header.Number, err)
+	}
+
+	batch := s.db.NewBatch()
+	for _, transaction := range block.Transactions {
+		data, err := json.Marshal(transaction)
+		if err != nil {
+			return err
+		}
+		if err := batch.Put(transactionKey(transaction.Hash), data); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(block)
+	if err != nil {
+		return err
+	}
+	if err := batch.Put(blockKey(number), data); err != nil {
+		return err
+	}
+	if err := batch.Put(headKey, encodeBlockNumber(number)); err != nil {
+		return err
+	}
+
+	return batch.Write()
+}
+
+// Rollback deletes every block above the given number together with its transactions.
+func (s *Store) Rollback(number uint64) error {
+	head, ok, err := s.Head()
+	if err != nil || !ok {
+		return err
+	}
+
+	batch := s.db.NewBatch()
+	for n := head; n > number; n-- {
+		block, ok, err := s.Block(n)
+		if err != nil {
+			return err
+		}
+		if ok {
+			for _, transaction := range block.Transactions {
+				if err := batch.Delete(transactionKey(transaction.Hash)); err != nil {
+					return err
+				}
+			}
+		}
+		if err := batch.Delete(blockKey(n)); err != nil {
+			return err
+		}
+	}
+
+	// Block 0 is never indexed, so rolling back to it empties the store
+	if number == 0 {
+		err = batch.Delete(headKey)
+	} else {
+		err = batch.Put(headKey, encodeBlockNumber(number))
+	}
+	if err != nil {
+		return err
+	}
+
+	return batch.Write()
+}
+
+// Wipe deletes everything from the store.
+func (s *Store) Wipe() error {
+	it := s.db.NewIterator(nil, nil)
+	defer it.Release()
+
+	batch := s.db.NewBatch()
+	for it.Next() {
+		if err := batch.Delete(common.CopyBytes(it.Key())); err != nil {
+			return err
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+
+	return batch.Write()
+}
+
+func (s *Store) get(key []byte) ([]byte, bool, error) {
+	ok, err := s.db.Has(key)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	value, err := s.db.Get(key)
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (s *Store) getJSON(key []byte, v interface{}) (bool, error) {
+	data, ok, err := s.get(key)
+	if err != nil || !ok {
+		return false, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func blockKey(number uint64) []byte {
+	return append(common.CopyBytes(blockPrefix), encodeBlockNumber(number)...)
+}
+
+func transactionKey(hash string) []byte {
+	return append(common.CopyBytes(transactionPrefix), common.HexToHash(hash).Bytes()...)
+}
+
+func encodeBlockNumber(number uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, number)
+	return enc
+}
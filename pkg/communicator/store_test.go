@@ -0,0 +1,68 @@
+package communicator
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStore(memorydb.New())
+	defer store.Close()
+
+	transactionHash := "0x1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a2b"
+	err := store.WriteBlock(Block{
+		Header:       Header{Number: "5"},
+		Transactions: []Transaction{{Hash: transactionHash, BlockNumber: "5"}},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	head, ok, err := store.Head()
+	if err != nil || !ok || head != 5 {
+		t.Fatalf("Expected head 5, got %d (%v, %v)", head, ok, err)
+	}
+	if _, ok, _ := store.Block(5); !ok {
+		t.Errorf("Expected block 5 to be stored")
+	}
+	if transaction, ok, _ := store.Transaction(transactionHash); !ok || transaction.BlockNumber != "5" {
+		t.Errorf("Expected transaction to be stored, got %v", transaction)
+	}
+
+	if err := store.Wipe(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, ok, _ := store.Head(); ok {
+		t.Errorf("Expected empty store after wipe")
+	}
+}
+
+func TestStoreRollback(t *testing.T) {
+	store := NewStore(memorydb.New())
+	defer store.Close()
+
+	transactionHash := "0x1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a2b"
+	for _, number := range []string{"1", "2", "3"} {
+		block := Block{Header: Header{Number: number}}
+		if number == "3" {
+			block.Transactions = []Transaction{{Hash: transactionHash}}
+		}
+		if err := store.WriteBlock(block); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if err := store.Rollback(1); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if head, _, _ := store.Head(); head != 1 {
+		t.Errorf("Expected head 1, got %d", head)
+	}
+	if _, ok, _ := store.Block(3); ok {
+		t.Errorf("Expected block 3 to be rolled back")
+	}
+	if _, ok, _ := store.Transaction(transactionHash); ok {
+		t.Errorf("Expected transaction of block 3 to be rolled back")
+	}
+}
@@ -0,0 +1,270 @@
+package communicator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	StreamEventBlock              = "block"
+	StreamEventPendingTransaction = "pending_transaction"
+	StreamEventChainReset         = "chain_reset"
+
+	DefaultStreamPollInterval = time.Second
+
+	// Number of streamed block hashes kept to find the common ancestor when polling
+	maxStreamedBlockHashes = 128
+)
+
+type StreamRequest struct {
+	// Push pending transactions as well, not only the mined blocks
+	PendingTransactions bool `json:"pending_transactions"`
+
+	// Poll interval used when the node doesn't support subscriptions
+	PollInterval time.Duration `json:"-"`
+}
+
+type StreamEvent struct {
+	Type        string           `json:"type"`
+	Block       *Block           `json:"block,omitempty"`
+	Transaction *Transaction     `json:"transaction,omitempty"`
+	ChainReset  *ChainResetEvent `json:"chain_reset,omitempty"`
+}
+
+// Stream pushes the new blocks (and pending transactions) of the node to the channel until the context is cancelled.
+// It subscribes via eth_subscribe when the node supports it and falls back to polling otherwise.
+func Stream(ctx context.Context, req StreamRequest, events chan<- StreamEvent) error {
+	return stream(ctx, req, events)
+}
+
+func stream(ctx context.Context, req StreamRequest, events chan<- StreamEvent) error {
+	if req.PollInterval <= 0 {
+		req.PollInterval = DefaultStreamPollInterval
+	}
+
+	rpcClient, err := rpc.DialContext(ctx, GetNodeAddress(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to connect to Ethereum client", slog.Any("err", err))
+		return err
+	}
+	defer rpcClient.Close()
+	client := ethclient.NewClient(rpcClient)
+
+	if indexer := getIndexer(ctx); indexer != nil {
+		resets := make(chan ChainResetEvent)
+		subscription := indexer.SubscribeChainResets(resets)
+		defer subscription.Unsubscribe()
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case reset := <-resets:
+					sendStreamEvent(ctx, events, StreamEvent{Type: StreamEventChainReset, ChainReset: &reset})
+				}
+			}
+		}()
+	}
+
+	if req.PendingTransactions {
+		hashes := make(chan common.Hash)
+		subscription, err := gethclient.New(rpcClient).SubscribePendingTransactions(ctx, hashes)
+		if err != nil {
+			slog.InfoContext(ctx, "Pending transaction subscription not supported, polling the pending block", slog.Any("err", err))
+			go pollPendingTransactions(ctx, client, req.PollInterval, events)
+		} else {
+			defer subscription.Unsubscribe()
+			go streamPendingTransactions(ctx, client, hashes, events)
+		}
+	}
+
+	headers := make(chan *types.Header)
+	subscription, err := client.SubscribeNewHead(ctx, headers)
+	if err != nil {
+		slog.InfoContext(ctx, "New head subscription not supported, polling the block number", slog.Any("err", err))
+		return pollBlocks(ctx, client, req.PollInterval, events)
+	}
+	defer subscription.Unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-subscription.Err():
+			slog.ErrorContext(ctx, "New head subscription failed", slog.Any("err", err))
+			return fmt.Errorf("new head subscription failed: %v", err)
+		case header := <-headers:
+			// A block that can't be retrieved is skipped, the stream goes on with the next head
+			_, _ = streamBlock(ctx, client, header.Number, events)
+		}
+	}
+}
+
+func pollBlocks(ctx context.Context, client *ethclient.Client, pollInterval time.Duration, events chan<- StreamEvent) error {
+	last, err := client.BlockNumber(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get latest block number", slog.Any("err", err))
+		return err
+	}
+	return pollBlocksFrom(ctx, client, last, pollInterval, events)
+}
+
+// pollBlocksFrom streams the blocks after last, errors are logged and retried on the next tick.
+func pollBlocksFrom(ctx context.Context, client *ethclient.Client, last uint64, pollInterval time.Duration, events chan<- StreamEvent) error {
+	hashes := make(map[uint64]common.Hash)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		head, err := client.BlockNumber(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to get latest block number", slog.Any("err", err))
+			continue
+		}
+
+		// Without subscriptions the head going backwards is the only sign of a reset
+		if head < last {
+			commonAncestor := streamCommonAncestor(ctx, client, hashes, head)
+			for number := range hashes {
+				if number > commonAncestor {
+					delete(hashes, number)
+				}
+			}
+			sendStreamEvent(ctx, events, StreamEvent{
+				Type: StreamEventChainReset,
+				ChainReset: &ChainResetEvent{
+					Reason:         ChainResetReasonHeadReversed,
+					OldHead:        last,
+					NewHead:        head,
+					CommonAncestor: commonAncestor,
+					Timestamp:      time.Now().Unix(),
+				},
+			})
+			last = head
+			continue
+		}
+
+		for ; last < head; last++ {
+			block, err := streamBlock(ctx, client, new(big.Int).SetUint64(last+1), events)
+			if err != nil {
+				break
+			}
+			hashes[last+1] = block.Hash()
+			delete(hashes, last+1-maxStreamedBlockHashes)
+		}
+	}
+}
+
+// streamCommonAncestor walks back from the head to the last streamed block the node still has,
+// zero if none of the streamed blocks is left.
+func streamCommonAncestor(ctx context.Context, client *ethclient.Client, hashes map[uint64]common.Hash, head uint64) uint64 {
+	for number := head; number > 0; number-- {
+		hash, ok := hashes[number]
+		if !ok {
+			return 0
+		}
+		header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to get header", slog.Any("block_number", number), slog.Any("err", err))
+			return 0
+		}
+		if header.Hash() == hash {
+			return number
+		}
+	}
+	return 0
+}
+
+func streamBlock(ctx context.Context, client *ethclient.Client, number *big.Int, events chan<- StreamEvent) (*types.Block, error) {
+	block, err := client.BlockByNumber(ctx, number)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to retrieve block", slog.Any("block_number", number), slog.Any("err", err))
+		return nil, err
+	}
+
+	parsedBlock, err := parseBlock(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+	sendStreamEvent(ctx, events, StreamEvent{Type: StreamEventBlock, Block: &parsedBlock})
+
+	return block, nil
+}
+
+func streamPendingTransactions(ctx context.Context, client *ethclient.Client, hashes <-chan common.Hash, events chan<- StreamEvent) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case hash := <-hashes:
+			transaction, _, err := client.TransactionByHash(ctx, hash)
+			if err != nil {
+				// It's common that the transaction is already mined or dropped
+				slog.InfoContext(ctx, "Failed to get pending transaction", slog.Any("hash", hash), slog.Any("err", err))
+				continue
+			}
+			streamPendingTransaction(ctx, transaction, events)
+		}
+	}
+}
+
+func pollPendingTransactions(ctx context.Context, client *ethclient.Client, pollInterval time.Duration, events chan<- StreamEvent) {
+	seen := make(map[common.Hash]struct{})
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		block, err := client.BlockByNumber(ctx, big.NewInt(int64(rpc.PendingBlockNumber)))
+		if err != nil {
+			slog.InfoContext(ctx, "Failed to get pending block", slog.Any("err", err))
+			continue
+		}
+
+		pending := make(map[common.Hash]struct{})
+		for _, transaction := range block.Transactions() {
+			pending[transaction.Hash()] = struct{}{}
+			if _, ok := seen[transaction.Hash()]; !ok {
+				streamPendingTransaction(ctx, transaction, events)
+			}
+		}
+		// Forget the mined transactions
+		seen = pending
+	}
+}
+
+func streamPendingTransaction(ctx context.Context, transaction *types.Transaction, events chan<- StreamEvent) {
+	parsedTransaction, err := parseTransaction(transaction, "", 0)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse transaction", slog.Any("hash", transaction.Hash()), slog.Any("err", err))
+		return
+	}
+	parsedTransaction.IsPending = true
+	sendStreamEvent(ctx, events, StreamEvent{Type: StreamEventPendingTransaction, Transaction: &parsedTransaction})
+}
+
+func sendStreamEvent(ctx context.Context, events chan<- StreamEvent, event StreamEvent) {
+	select {
+	case <-ctx.Done():
+	case events <- event:
+	}
+}
@@ -0,0 +1,49 @@
+package communicator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+func TestPollBlocks(t *testing.T) {
+	chain := newTestChain(t, []*types.Transaction{}, []*types.Transaction{}, []*types.Transaction{})
+	blocks := chain.blocks
+	ctx, cancel := context.WithCancel(newTestNode(t, map[string]interface{}{"eth": chain}))
+	defer cancel()
+	client, err := ethclient.DialContext(ctx, GetNodeAddress(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := make(chan StreamEvent)
+	done := make(chan error)
+	go func() {
+		done <- pollBlocksFrom(ctx, client, 0, 10*time.Millisecond, events)
+	}()
+
+	// The new blocks are streamed in order
+	for _, number := range []string{"1", "2", "3"} {
+		event := <-events
+		if event.Type != StreamEventBlock || event.Block.Header.Number != number {
+			t.Fatalf("Expected block %s, got %+v", number, event)
+		}
+	}
+
+	// Block 2 is replaced, block 1 is the last streamed block the node still has
+	header := blocks[2].Header()
+	header.Extra = []byte("fork")
+	chain.setBlocks([]*types.Block{blocks[0], blocks[1], types.NewBlockWithHeader(header)})
+	event := <-events
+	if event.Type != StreamEventChainReset || event.ChainReset.OldHead != 3 || event.ChainReset.NewHead != 2 || event.ChainReset.CommonAncestor != 1 {
+		t.Fatalf("Expected a chain reset to block 1, got %+v", event)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected the poller to stop with the context, got %v", err)
+	}
+}
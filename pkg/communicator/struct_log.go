@@ -0,0 +1,177 @@
+package communicator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// structLogResult is the output of the default (struct log) tracer
+type structLogResult struct {
+	Gas         uint64      `json:"gas"`
+	Failed      bool        `json:"failed"`
+	ReturnValue string      `json:"returnValue"`
+	StructLogs  []structLog `json:"structLogs"`
+}
+
+type structLog struct {
+	Pc      uint64            `json:"pc"`
+	Op      string            `json:"op"`
+	Gas     uint64            `json:"gas"`
+	GasCost uint64            `json:"gasCost"`
+	Depth   int               `json:"depth"`
+	Error   string            `json:"error,omitempty"`
+	Stack   []string          `json:"stack,omitempty"`
+	Memory  []string          `json:"memory,omitempty"`
+	Storage map[string]string `json:"storage,omitempty"`
+}
+
+// structLogFrame is the call frame a struct log step is executed in
+type structLogFrame struct {
+	call     *callTracerFrame
+	parent   *structLogFrame
+	children []*structLogFrame
+
+	// Index of the next callTracer child frame to be entered
+	nextCall int
+}
+
+// codeAddress returns the address of the executed code, creations execute init code which has no address yet.
+func (f *structLogFrame) codeAddress() (common.Address, bool) {
+	if f.call.To == nil || isCreateOp(f.call.Type) {
+		return common.Address{}, false
+	}
+	return *f.call.To, true
+}
+
+// traceStructLogs fetches both the struct log and the call trace of the transaction, the call trace
+// names the frames the struct log steps are executed in.
+func traceStructLogs(ctx context.Context, client *rpc.Client, hash common.Hash, config map[string]interface{}) (structLogResult, callTracerFrame, error) {
+	var result structLogResult
+	if err := client.CallContext(ctx, &result, "debug_traceTransaction", hash, config); err != nil {
+		slog.ErrorContext(ctx, "Failed to trace transaction", slog.Any("hash", hash.Hex()), slog.Any("err", err))
+		return structLogResult{}, callTracerFrame{}, fmt.Errorf("failed to trace transaction: %v", err)
+	}
+
+	var frame callTracerFrame
+	err := client.CallContext(ctx, &frame, "debug_traceTransaction", hash, map[string]interface{}{
+		"tracer": "callTracer",
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to trace transaction calls", slog.Any("hash", hash.Hex()), slog.Any("err", err))
+		return structLogResult{}, callTracerFrame{}, fmt.Errorf("failed to trace transaction: %v", err)
+	}
+
+	return result, frame, nil
+}
+
+// walkStructLogs calls fn with every step and the frame executing it. The callTracer reports a frame for
+// every call opcode, even for the ones which don't execute code (precompiles, accounts without code), so
+// a child frame is consumed on each call opcode and entered if the next step is deeper.
+func walkStructLogs(logs []structLog, root *callTracerFrame, fn func(index int, frame *structLogFrame)) *structLogFrame {
+	rootFrame := &structLogFrame{call: root}
+	frame := rootFrame
+	depth := 1
+	for i, step := range logs {
+		for depth > step.Depth && frame.parent != nil {
+			frame = frame.parent
+			depth--
+		}
+		fn(i, frame)
+
+		if !isCallOp(step.Op) {
+			continue
+		}
+		var call *callTracerFrame
+		if frame.nextCall < len(frame.call.Calls) {
+			call = &frame.call.Calls[frame.nextCall]
+			frame.nextCall++
+		}
+		if i+1 < len(logs) && logs[i+1].Depth > step.Depth {
+			if call == nil {
+				call = &callTracerFrame{Type: step.Op}
+			}
+			child := &structLogFrame{call: call, parent: frame}
+			frame.children = append(frame.children, child)
+			frame = child
+			depth++
+		}
+	}
+
+	return rootFrame
+}
+
+// structLogGasCosts returns the gas spent by every step. The gas cost reported for call opcodes contains
+// the gas passed to the callee, so their cost is taken from the gas left after the call returned minus
+// the gas used by the callee.
+func structLogGasCosts(logs []structLog) []uint64 {
+	costs := make([]uint64, len(logs))
+
+	type openCall struct {
+		index    int
+		childGas uint64
+	}
+	var open []openCall
+	record := func(index int, self, inclusive uint64) {
+		costs[index] = self
+		if len(open) > 0 && logs[open[len(open)-1].index].Depth == logs[index].Depth-1 {
+			open[len(open)-1].childGas += inclusive
+		}
+	}
+	closeCall := func(gasAfter uint64, sameDepth bool) {
+		call := open[len(open)-1]
+		open = open[:len(open)-1]
+		inclusive := logs[call.index].GasCost
+		if sameDepth {
+			inclusive = subGas(logs[call.index].Gas, gasAfter)
+		}
+		record(call.index, subGas(inclusive, call.childGas), inclusive)
+	}
+
+	for i, step := range logs {
+		for len(open) > 0 && logs[open[len(open)-1].index].Depth >= step.Depth {
+			closeCall(step.Gas, logs[open[len(open)-1].index].Depth == step.Depth)
+		}
+
+		switch {
+		case i+1 == len(logs):
+			record(i, step.GasCost, step.GasCost)
+		case logs[i+1].Depth == step.Depth:
+			cost := subGas(step.Gas, logs[i+1].Gas)
+			record(i, cost, cost)
+		case logs[i+1].Depth > step.Depth:
+			open = append(open, openCall{index: i})
+		default:
+			// Last step of a frame
+			record(i, step.GasCost, step.GasCost)
+		}
+	}
+	// Calls still open when the execution stopped, e.g. out of gas in the callee
+	for len(open) > 0 {
+		closeCall(0, false)
+	}
+
+	return costs
+}
+
+func subGas(a, b uint64) uint64 {
+	if b > a {
+		return 0
+	}
+	return a - b
+}
+
+func isCallOp(op string) bool {
+	switch op {
+	case "CALL", "CALLCODE", "DELEGATECALL", "STATICCALL", "CREATE", "CREATE2", "SELFDESTRUCT":
+		return true
+	}
+	return false
+}
+
+func isCreateOp(op string) bool {
+	return op == "CREATE" || op == "CREATE2"
+}
@@ -0,0 +1,147 @@
+package communicator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type TraceTransactionRequest struct {
+	Hash string `json:"hash"`
+
+	// ABI used for every callee which has no address specific ABI
+	ContractABI string `json:"contract_abi"`
+
+	// ABIs keyed by contract address
+	ContractABIs map[string]string `json:"contract_abis"`
+}
+
+type TraceTransactionResponse struct {
+	Trace CallFrame `json:"trace"`
+}
+
+type CallFrame struct {
+	Type    string `json:"type"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Value   string `json:"value"`
+	Gas     uint64 `json:"gas"`
+	GasUsed uint64 `json:"gas_used"`
+	Input   string `json:"input"`
+	Output  string `json:"output"`
+	Error   string `json:"error,omitempty"`
+
+	// Filled if the ABI of the callee is known
+	FunctionName string                 `json:"function_name,omitempty"`
+	Signature    string                 `json:"signature,omitempty"`
+	Args         map[string]interface{} `json:"args,omitempty"`
+	Outputs      map[string]interface{} `json:"outputs,omitempty"`
+	Revert       *RevertReason          `json:"revert,omitempty"`
+
+	Calls []CallFrame `json:"calls"`
+}
+
+// callTracerFrame is the frame format returned by the callTracer
+type callTracerFrame struct {
+	Type    string            `json:"type"`
+	From    common.Address    `json:"from"`
+	To      *common.Address   `json:"to"`
+	Value   *hexutil.Big      `json:"value"`
+	Gas     hexutil.Uint64    `json:"gas"`
+	GasUsed hexutil.Uint64    `json:"gasUsed"`
+	Input   hexutil.Bytes     `json:"input"`
+	Output  hexutil.Bytes     `json:"output"`
+	Error   string            `json:"error"`
+	Calls   []callTracerFrame `json:"calls"`
+}
+
+func TraceTransaction(ctx context.Context, req TraceTransactionRequest) (TraceTransactionResponse, error) {
+	return traceTransaction(ctx, req)
+}
+
+func traceTransaction(ctx context.Context, req TraceTransactionRequest) (TraceTransactionResponse, error) {
+	abis, err := parseContractABIs(ctx, req.ContractABI, req.ContractABIs)
+	if err != nil {
+		return TraceTransactionResponse{}, err
+	}
+
+	client, err := rpc.DialContext(ctx, GetNodeAddress(ctx))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to connect to Ethereum client", slog.Any("err", err))
+		return TraceTransactionResponse{}, err
+	}
+	defer client.Close()
+
+	var frame callTracerFrame
+	err = client.CallContext(ctx, &frame, "debug_traceTransaction", common.HexToHash(req.Hash), map[string]interface{}{
+		"tracer": "callTracer",
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to trace transaction", slog.Any("hash", req.Hash), slog.Any("err", err))
+		return TraceTransactionResponse{}, fmt.Errorf("failed to trace transaction: %v", err)
+	}
+
+	return TraceTransactionResponse{
+		Trace: parseCallFrame(ctx, abis, frame),
+	}, nil
+}
+
+func parseCallFrame(ctx context.Context, abis contractABIs, frame callTracerFrame) CallFrame {
+	parsed := CallFrame{
+		Type:    strings.ToUpper(frame.Type),
+		From:    frame.From.Hex(),
+		To:      safeHexAddress(frame.To),
+		Value:   safeBigIntToString(frame.Value.ToInt()),
+		Gas:     uint64(frame.Gas),
+		GasUsed: uint64(frame.GasUsed),
+		Input:   hexutil.Encode(frame.Input),
+		Output:  hexutil.Encode(frame.Output),
+		Error:   frame.Error,
+		Calls:   make([]CallFrame, 0, len(frame.Calls)),
+	}
+
+	// Creations have init code as input, only calls can be decoded
+	if frame.To != nil && !strings.HasPrefix(parsed.Type, "CREATE") {
+		decodeCallFrame(ctx, abis.get(ctx, *frame.To), frame, &parsed)
+	}
+
+	for _, call := range frame.Calls {
+		parsed.Calls = append(parsed.Calls, parseCallFrame(ctx, abis, call))
+	}
+
+	return parsed
+}
+
+func decodeCallFrame(ctx context.Context, contractABI *abi.ABI, frame callTracerFrame, parsed *CallFrame) {
+	if frame.Error != "" && len(frame.Output) > 0 {
+		revert := decodeRevert(contractABI, frame.Output)
+		parsed.Revert = &revert
+	}
+	if contractABI == nil || len(frame.Input) < 4 {
+		return
+	}
+
+	method, args, err := decodeCallData(*contractABI, frame.Input)
+	if err != nil {
+		slog.DebugContext(ctx, "Failed to decode call frame input", slog.Any("to", frame.To), slog.Any("err", err))
+		return
+	}
+	parsed.FunctionName = method.Name
+	parsed.Signature = method.Sig
+	parsed.Args = args
+
+	if frame.Error == "" {
+		outputs, err := unpackToJSON(method.Outputs, frame.Output, "output")
+		if err != nil {
+			slog.InfoContext(ctx, "Failed to decode call output", slog.Any("method", method.Sig), slog.Any("err", err))
+			return
+		}
+		parsed.Outputs = outputs
+	}
+}
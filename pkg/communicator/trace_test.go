@@ -0,0 +1,57 @@
+package communicator
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestParseCallFrame(t *testing.T) {
+	// callTracer output of a contract forwarding an ERC20 transfer
+	rawTrace := `{
+		"type": "CALL",
+		"from": "0xf39fd6e51aad88f6f4ce6ab8827279cfffb92266",
+		"to": "0xe7f1725e7734ce288f8367e1bb143e90bb3f0512",
+		"value": "0x0",
+		"gas": "0x1e8480",
+		"gasUsed": "0x9c40",
+		"input": "0x12345678",
+		"output": "0x",
+		"calls": [{
+			"type": "CALL",
+			"from": "0xe7f1725e7734ce288f8367e1bb143e90bb3f0512",
+			"to": "0x5fbdb2315678afecb367f032d93f642f64180aa3",
+			"gas": "0x1d4c0",
+			"gasUsed": "0x7530",
+			"input": "0xa9059cbb0000000000000000000000002857d75d6f42052ee415396ef1989c96b0768c7c00000000000000000000000000000000000000000000000000000000447bd088",
+			"output": "0x0000000000000000000000000000000000000000000000000000000000000001"
+		}]
+	}`
+	var frame callTracerFrame
+	if err := json.Unmarshal([]byte(rawTrace), &frame); err != nil {
+		t.Fatalf("Failed to unmarshal trace: %v", err)
+	}
+
+	abis, err := parseContractABIs(context.Background(), "", map[string]string{
+		"0x5FbDB2315678afecb367f032d93F642f64180aa3": contractABI,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	parsed := parseCallFrame(context.Background(), abis, frame)
+	if parsed.GasUsed != 40000 || len(parsed.Calls) != 1 {
+		t.Fatalf("Unexpected root frame %v", parsed)
+	}
+	if parsed.FunctionName != "" {
+		t.Errorf("Expected root frame without ABI to stay undecoded, got '%s'", parsed.FunctionName)
+	}
+
+	call := parsed.Calls[0]
+	if call.FunctionName != "transfer" || call.Value != "0" {
+		t.Errorf("Expected decoded transfer call, got %v", call)
+	}
+	if call.Outputs["output_0"] != true {
+		t.Errorf("Expected decoded output, got %v", call.Outputs)
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"log/slog"
 	"math/big"
+	"strings"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -34,7 +36,21 @@ type Transaction struct {
 	Type             string   `json:"type"`
 	Method           string   `json:"method"`
 
-	IsPending bool `json:"isPending"`
+	IsPending bool          `json:"isPending"`
+	Receipt   *Receipt      `json:"receipt,omitempty"`
+	Revert    *RevertReason `json:"revert,omitempty"`
+
+	// Filled if the ABI of the called contract is registered or the selector is in the signature database
+	Call *DecodedCall `json:"call,omitempty"`
+}
+
+type DecodedCall struct {
+	ContractName string                 `json:"contract_name,omitempty"`
+	FunctionName string                 `json:"function_name"`
+	Signature    string                 `json:"signature"`
+	Args         map[string]interface{} `json:"args"`
+	Summary      string                 `json:"summary"` // e.g. USDC.transfer(to, amount)
+	Inferred     bool                   `json:"inferred"`
 }
 
 func GetTransactionByHash(ctx context.Context, req GetTransactionByHashRequest) (Transaction, error) {
@@ -48,6 +64,13 @@ func getTransactionByHash(ctx context.Context, req GetTransactionByHashRequest)
 		return Transaction{}, err
 	}
 
+	if indexer := getIndexer(ctx); indexer != nil && indexer.isConsistent() {
+		if transaction, ok := indexer.transaction(ctx, req.Hash); ok {
+			transaction.Call = decodeTransactionCall(ctx, transaction)
+			return transaction, nil
+		}
+	}
+
 	transaction, isPending, err := client.TransactionByHash(ctx, common.HexToHash(req.Hash))
 	if err != nil {
 		slog.ErrorContext(ctx, "Failed to get transaction by hash", slog.Any("hash", req.Hash), slog.Any("err", err))
@@ -59,9 +82,94 @@ func getTransactionByHash(ctx context.Context, req GetTransactionByHashRequest)
 		return Transaction{}, err
 	}
 	parsedTransaction.IsPending = isPending
+
+	// Pending transactions don't have a receipt yet
+	if isPending {
+		return parsedTransaction, nil
+	}
+
+	receipt, err := client.TransactionReceipt(ctx, transaction.Hash())
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get transaction receipt", slog.Any("hash", req.Hash), slog.Any("err", err))
+		return Transaction{}, err
+	}
+	parsedReceipt := parseReceipt(receipt)
+	parsedTransaction.BlockNumber = parsedReceipt.BlockNumber
+	parsedTransaction.TransactionIndex = int64(parsedReceipt.TransactionIndex)
+	parsedTransaction.Receipt = &parsedReceipt
+
+	if receipt.Status == types.ReceiptStatusFailed {
+		var contractABI *abi.ABI
+		if transaction.To() != nil {
+			contractABI = registeredContractABI(ctx, *transaction.To())
+		}
+		revert, err := replayRevert(ctx, client, transaction, receipt.BlockNumber, contractABI)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to get revert reason", slog.Any("hash", req.Hash), slog.Any("err", err))
+		}
+		parsedTransaction.Revert = revert
+	}
+	parsedTransaction.Call = decodeTransactionCall(ctx, parsedTransaction)
+
 	return parsedTransaction, nil
 }
 
+// decodeTransactionCall decodes the input with the registered ABI of the recipient, or with the signature
+// database if it's unknown. Nil is returned if neither knows the function.
+func decodeTransactionCall(ctx context.Context, transaction Transaction) *DecodedCall {
+	if transaction.To == "" {
+		return nil
+	}
+	data := common.FromHex(transaction.Input)
+	if len(data) < 4 {
+		return nil
+	}
+	to := common.HexToAddress(transaction.To)
+
+	var (
+		method   abi.Method
+		args     map[string]interface{}
+		inferred bool
+		err      error
+	)
+	if contractABI := registeredContractABI(ctx, to); contractABI != nil {
+		method, args, err = decodeCallData(*contractABI, data)
+	}
+	if args == nil {
+		var ok bool
+		method, args, ok = inferCallData(ctx, data)
+		if !ok {
+			if err != nil {
+				slog.InfoContext(ctx, "Failed to decode transaction input", slog.Any("hash", transaction.Hash), slog.Any("err", err))
+			}
+			return nil
+		}
+		inferred = true
+	}
+
+	call := &DecodedCall{
+		ContractName: registeredContractName(ctx, to),
+		FunctionName: method.Name,
+		Signature:    method.Sig,
+		Args:         args,
+		Inferred:     inferred,
+	}
+	argNames := make([]string, 0, len(method.Inputs))
+	for i, input := range method.Inputs {
+		name := input.Name
+		if name == "" {
+			name = fmt.Sprintf("input_%d", i)
+		}
+		argNames = append(argNames, name)
+	}
+	call.Summary = fmt.Sprintf("%s(%s)", method.Name, strings.Join(argNames, ", "))
+	if call.ContractName != "" {
+		call.Summary = call.ContractName + "." + call.Summary
+	}
+
+	return call
+}
+
 func parseTransaction(transaction *types.Transaction, blockNumber string, index int64) (Transaction, error) {
 	chainID := transaction.ChainId()
 	if chainID == nil || chainID.Int64() == 0 {